@@ -0,0 +1,239 @@
+// config 命令：查看和检查应用实际使用的配置
+//
+// 与服务使用相同的 config.Load（默认值、配置文件、环境变量、命令行参数、密钥引用、验证），
+// 因此看到的就是服务启动时的配置。
+//
+// 使用示例：
+//
+//	go run ./cmd/config print                                  # 以 YAML 输出最终配置（密钥脱敏）
+//	go run ./cmd/config print --format json --env prod         # 以 JSON 输出 prod 环境的配置
+//	go run ./cmd/config validate --config config/config.yaml   # 验证配置，有错误时退出码为 1（用于 CI）
+//	go run ./cmd/config diff --against-env prod                # 对比当前环境与 prod 环境的配置
+//	go run ./cmd/config diff --against /tmp/config.yaml        # 对比当前配置文件与另一个配置文件
+//	go run ./cmd/config validate --strict                      # 严格模式：配置文件中有未知配置项时报错
+//	go run ./cmd/config schema > config/config.schema.json     # 生成配置文件的 JSON Schema
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/jingpc/awesome-be/internal/config"
+)
+
+// 退出码
+const (
+	exitOK     = 0 // 成功（验证通过、没有差异）
+	exitFailed = 1 // 验证失败、存在差异
+	exitUsage  = 2 // 参数错误、配置无法加载
+)
+
+// commandHelp 命令说明（后面接着输出参数说明）
+const commandHelp = `Usage: config <command> [flags]
+
+Commands:
+  print      输出最终配置（密钥脱敏）
+  validate   验证配置，列出所有错误
+  diff       对比两份配置（--against 或 --against-env）
+  schema     输出配置文件的 JSON Schema
+
+Flags:
+`
+
+func main() {
+	// 子命令的参数需要在 config.Load 解析命令行参数之前定义
+	format := pflag.String("format", "yaml", "print 的输出格式 (yaml/json)")
+	against := pflag.String("against", "", "diff 对比的配置文件")
+	againstEnv := pflag.String("against-env", "", "diff 对比的运行环境 (dev/test/prod)")
+	pflag.Usage = func() {
+		fmt.Fprint(os.Stderr, commandHelp)
+		pflag.PrintDefaults()
+	}
+
+	command := ""
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+	switch command {
+	case "print", "validate", "diff":
+	case "schema":
+		// Schema 只依赖 Config 结构体，不需要加载配置
+		os.Exit(runSchema())
+	default:
+		if command != "" && !strings.HasPrefix(command, "-") {
+			fmt.Fprintf(os.Stderr, "unknown command %q\n\n", command)
+		}
+		pflag.Usage()
+		os.Exit(exitUsage)
+	}
+
+	// 加载配置的同时解析命令行参数，之后才能读取子命令的参数
+	cfg, err := config.Load()
+	if err != nil {
+		os.Exit(printLoadError(err))
+	}
+
+	switch command {
+	case "print":
+		os.Exit(runPrint(cfg, *format))
+	case "validate":
+		// 验证在 config.Load 中完成，能加载就是有效的
+		fmt.Println("config is valid")
+	case "diff":
+		os.Exit(runDiff(cfg, *against, *againstEnv))
+	}
+}
+
+// runPrint 输出脱敏后的最终配置
+func runPrint(cfg *config.Config, format string) int {
+	var out []byte
+	var err error
+	switch format {
+	case "yaml":
+		out, err = yaml.Marshal(cfg.Redacted())
+	case "json":
+		out, err = json.MarshalIndent(cfg.Redacted(), "", "  ")
+		out = append(out, '\n')
+	default:
+		fmt.Fprintf(os.Stderr, "--format must be one of: yaml, json\n")
+		return exitUsage
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to encode config: %v\n", err)
+		return exitUsage
+	}
+
+	os.Stdout.Write(out)
+	return exitOK
+}
+
+// runSchema 输出配置文件的 JSON Schema
+func runSchema() int {
+	out, err := json.MarshalIndent(config.JSONSchema(), "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to encode schema: %v\n", err)
+		return exitUsage
+	}
+
+	os.Stdout.Write(append(out, '\n'))
+	return exitOK
+}
+
+// runDiff 对比当前配置与另一个配置文件或运行环境的配置
+//
+// 架构思路：
+// - 修改 --config 或 --env 参数后重新加载一次（config.Load 每次都读取当前的命令行参数）
+// - 两份配置都展开为 databases[0].master.host 这样的配置项逐项对比，输出与 diff 命令类似
+// - 密钥脱敏后对比，只显示 ****** 是否设置，不会输出密钥内容
+func runDiff(current *config.Config, against, againstEnv string) int {
+	if (against == "") == (againstEnv == "") {
+		fmt.Fprintf(os.Stderr, "diff requires exactly one of --against or --against-env\n")
+		return exitUsage
+	}
+
+	flag, value, label := "config", against, against
+	if againstEnv != "" {
+		flag, value, label = "env", againstEnv, "env "+againstEnv
+	}
+	if err := pflag.Set(flag, value); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid --%s: %v\n", flag, err)
+		return exitUsage
+	}
+	other, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: ", label)
+		return printLoadError(err)
+	}
+	a, b := make(map[string]string), make(map[string]string)
+	flatten("", current.Redacted(), a)
+	flatten("", other.Redacted(), b)
+
+	keys := make(map[string]bool)
+	for key := range a {
+		keys[key] = true
+	}
+	for key := range b {
+		keys[key] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	fmt.Printf("--- current\n+++ %s\n", label)
+	changed := false
+	for _, key := range sorted {
+		va, okA := a[key]
+		vb, okB := b[key]
+		if okA && okB && va == vb {
+			continue
+		}
+		changed = true
+		if okA {
+			fmt.Printf("- %s: %s\n", key, va)
+		}
+		if okB {
+			fmt.Printf("+ %s: %s\n", key, vb)
+		}
+	}
+
+	if changed {
+		return exitFailed
+	}
+	fmt.Println("no differences")
+	return exitOK
+}
+
+// printLoadError 输出加载配置的错误，验证错误逐行输出
+func printLoadError(err error) int {
+	var errs config.ValidationErrors
+	if !errors.As(err, &errs) {
+		fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+		return exitUsage
+	}
+
+	fmt.Fprintf(os.Stderr, "config is invalid (%d errors):\n", len(errs))
+	for _, fe := range errs {
+		fmt.Fprintf(os.Stderr, "  %s\n", fe)
+	}
+	return exitFailed
+}
+
+// flatten 将配置展开为 配置项 -> 值（结构体数组按下标展开，基本类型数组作为一个值）
+func flatten(prefix string, value any, out map[string]string) {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			flatten(key, child, out)
+		}
+
+	case []any:
+		if len(v) > 0 {
+			if _, ok := v[0].(map[string]any); ok {
+				for i, child := range v {
+					flatten(fmt.Sprintf("%s[%d]", prefix, i), child, out)
+				}
+				return
+			}
+		}
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = fmt.Sprint(item)
+		}
+		out[prefix] = "[" + strings.Join(items, ", ") + "]"
+
+	default:
+		out[prefix] = fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,277 @@
+// migrate 命令：管理数据库迁移
+//
+// 与服务使用相同的 config.Load 读取数据库配置，迁移文件默认在 migrations/数据库名称 目录下。
+//
+// 使用示例：
+//
+//	go run ./cmd/migrate create add_users --database main   # 创建迁移文件
+//	go run ./cmd/migrate up                                  # 所有数据库执行未执行的迁移
+//	go run ./cmd/migrate status                              # 查看所有数据库的迁移状态
+//	go run ./cmd/migrate down 2 --database main              # 回滚最近 2 个迁移
+//	go run ./cmd/migrate goto 20250101120000 --database main # 迁移到指定版本（0 表示全部回滚）
+//	go run ./cmd/migrate up --env prod                       # 使用 prod 环境的数据库配置
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/jingpc/awesome-be/internal/config"
+	"github.com/jingpc/awesome-be/internal/database"
+	"github.com/jingpc/awesome-be/internal/logger"
+	"github.com/jingpc/awesome-be/internal/migrate"
+)
+
+// 退出码
+const (
+	exitOK     = 0 // 成功
+	exitFailed = 1 // 迁移失败、数据库无法连接
+	exitUsage  = 2 // 参数错误、配置无法加载
+)
+
+// commandHelp 命令说明（后面接着输出参数说明）
+const commandHelp = `Usage: migrate <command> [args] [flags]
+
+Commands:
+  up               执行所有未执行的迁移
+  down [N]         回滚最近 N 个迁移（默认 1）
+  status           查看迁移状态
+  goto VERSION     迁移到指定版本（版本更高时执行，更低时回滚，0 表示全部回滚）
+  create NAME      创建一对新的迁移文件（up、down）
+
+up、status 默认处理所有数据库，其他命令在配置了多个数据库时需要 --database。
+
+Flags:
+`
+
+// usageError 参数错误
+type usageError struct {
+	msg string
+}
+
+func (e usageError) Error() string {
+	return e.msg
+}
+
+func main() {
+	// 命令的参数需要在 config.Load 解析命令行参数之前定义
+	dbName := pflag.String("database", "", "数据库名称（databases[].name）")
+	pflag.Usage = func() {
+		fmt.Fprint(os.Stderr, commandHelp)
+		pflag.PrintDefaults()
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+		os.Exit(exitUsage)
+	}
+
+	args := pflag.Args()
+	if len(args) == 0 {
+		pflag.Usage()
+		os.Exit(exitUsage)
+	}
+
+	err = run(cfg, args[0], args[1:], *dbName)
+	var usageErr usageError
+	switch {
+	case err == nil:
+		os.Exit(exitOK)
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(os.Stderr, "%v\n\n", err)
+		pflag.Usage()
+		os.Exit(exitUsage)
+	default:
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(exitFailed)
+	}
+}
+
+// run 执行命令
+func run(cfg *config.Config, command string, args []string, dbName string) error {
+	switch command {
+	case "up", "status":
+		if len(args) > 0 {
+			return usageError{fmt.Sprintf("%s takes no arguments", command)}
+		}
+		targets, err := selectDatabases(cfg.Databases, dbName, true)
+		if err != nil {
+			return err
+		}
+		return forEach(cfg, targets, func(ctx context.Context, m *migrate.Migrator, db config.DatabaseConfig) error {
+			if command == "status" {
+				return printStatus(ctx, m, db)
+			}
+			applied, err := m.Up(ctx)
+			printDone(db.Name, "applied", applied)
+			return finish(db.Name, len(applied), err)
+		})
+
+	case "down", "goto":
+		targets, err := selectDatabases(cfg.Databases, dbName, false)
+		if err != nil {
+			return err
+		}
+
+		n, version := 1, int64(0)
+		switch command {
+		case "down":
+			if len(args) > 1 {
+				return usageError{"usage: migrate down [N]"}
+			}
+			if len(args) == 1 {
+				if n, err = strconv.Atoi(args[0]); err != nil || n <= 0 {
+					return usageError{"down takes a positive number of migrations"}
+				}
+			}
+		case "goto":
+			if len(args) != 1 {
+				return usageError{"usage: migrate goto VERSION"}
+			}
+			if version, err = strconv.ParseInt(args[0], 10, 64); err != nil || version < 0 {
+				return usageError{"goto takes a migration version (0 to roll back all)"}
+			}
+		}
+
+		return forEach(cfg, targets, func(ctx context.Context, m *migrate.Migrator, db config.DatabaseConfig) error {
+			if command == "down" {
+				rolledBack, err := m.Down(ctx, n)
+				printDone(db.Name, "rolled back", rolledBack)
+				return finish(db.Name, len(rolledBack), err)
+			}
+			applied, rolledBack, err := m.Goto(ctx, version)
+			printDone(db.Name, "rolled back", rolledBack)
+			printDone(db.Name, "applied", applied)
+			return finish(db.Name, len(applied)+len(rolledBack), err)
+		})
+
+	case "create":
+		if len(args) != 1 {
+			return usageError{"usage: migrate create NAME"}
+		}
+		targets, err := selectDatabases(cfg.Databases, dbName, false)
+		if err != nil {
+			return err
+		}
+		upPath, downPath, err := migrate.Create(migrate.Dir(targets[0]), args[0], time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to create migration: %w", err)
+		}
+		fmt.Printf("created %s\ncreated %s\n", upPath, downPath)
+		return nil
+
+	default:
+		return usageError{fmt.Sprintf("unknown command %q", command)}
+	}
+}
+
+// selectDatabases 按 --database 选择数据库，all 表示未指定时选择全部
+func selectDatabases(databases []config.DatabaseConfig, name string, all bool) ([]config.DatabaseConfig, error) {
+	if len(databases) == 0 {
+		return nil, usageError{"no databases configured"}
+	}
+
+	if name == "" {
+		if all || len(databases) == 1 {
+			return databases, nil
+		}
+		return nil, usageError{"--database is required when multiple databases are configured"}
+	}
+
+	for _, db := range databases {
+		if db.Name == name {
+			return []config.DatabaseConfig{db}, nil
+		}
+	}
+	return nil, usageError{fmt.Sprintf("database %q is not configured", name)}
+}
+
+// forEach 依次连接数据库并执行 fn（遇到错误停止）
+func forEach(cfg *config.Config, databases []config.DatabaseConfig, fn func(ctx context.Context, m *migrate.Migrator, db config.DatabaseConfig) error) error {
+	log, err := logger.New(cfg.Logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	defer log.Sync()
+
+	ctx := context.Background()
+	for _, dbCfg := range databases {
+		if err := migrateDatabase(ctx, dbCfg, log, fn); err != nil {
+			return fmt.Errorf("%s: %w", dbCfg.Name, err)
+		}
+	}
+	return nil
+}
+
+// migrateDatabase 连接单个数据库并执行 fn
+func migrateDatabase(ctx context.Context, dbCfg config.DatabaseConfig, log *logger.Logger, fn func(ctx context.Context, m *migrate.Migrator, db config.DatabaseConfig) error) error {
+	// 迁移直接使用 database/sql 执行，GORM 只用来建立连接，不输出连接检查等 SQL 日志
+	dbCfg.LogLevel = "warn"
+	db, err := database.New(dbCfg, log, nil)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	m, err := migrate.Open(db, dbCfg)
+	if err != nil {
+		return err
+	}
+	return fn(ctx, m, dbCfg)
+}
+
+// printDone 输出本次执行或回滚的迁移（出错时也输出已经完成的部分）
+func printDone(dbName, action string, done []migrate.Migration) {
+	for _, m := range done {
+		fmt.Printf("%s: %s %d_%s\n", dbName, action, m.Version, m.Name)
+	}
+}
+
+// finish 成功且没有需要处理的迁移时输出 nothing to do
+func finish(dbName string, count int, err error) error {
+	if err == nil && count == 0 {
+		fmt.Printf("%s: nothing to do\n", dbName)
+	}
+	return err
+}
+
+// printStatus 以表格输出迁移状态
+func printStatus(ctx context.Context, m *migrate.Migrator, db config.DatabaseConfig) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s (%s):\n", db.Name, migrate.Dir(db))
+	if len(statuses) == 0 {
+		fmt.Println("  no migrations")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, s := range statuses {
+		status, appliedAt := "pending", ""
+		if s.Applied {
+			status, appliedAt = "applied", s.AppliedAt.Local().Format(time.DateTime)
+		}
+		switch {
+		case s.Dirty:
+			status = "dirty"
+		case s.Missing:
+			status += " (file missing)"
+		case s.Modified:
+			status += " (modified)"
+		}
+		fmt.Fprintf(w, "  %d\t%s\t%s\t%s\n", s.Version, s.Name, status, appliedAt)
+	}
+	return w.Flush()
+}
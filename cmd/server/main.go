@@ -3,8 +3,13 @@ package main
 import (
 	"context"
 	"fmt"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"reflect"
+	"strconv"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -13,8 +18,11 @@ import (
 	"github.com/jingpc/awesome-be/internal/database"
 	"github.com/jingpc/awesome-be/internal/health"
 	"github.com/jingpc/awesome-be/internal/logger"
+	"github.com/jingpc/awesome-be/internal/metrics"
+	"github.com/jingpc/awesome-be/internal/migrate"
 	"github.com/jingpc/awesome-be/internal/redis"
 	"github.com/jingpc/awesome-be/internal/router"
+	"github.com/jingpc/awesome-be/internal/tracing"
 	"github.com/jingpc/awesome-be/pkg/errors"
 	"github.com/jingpc/awesome-be/pkg/middleware"
 	"github.com/jingpc/awesome-be/pkg/response"
@@ -23,12 +31,14 @@ import (
 func main() {
 	// ==================== 第一阶段：初始化配置 ====================
 	// 配置是整个应用的基础，必须最先加载 ✅
-	cfg, err := config.Load()
+	// 使用 Watcher 加载，启动完成后监听配置变化（热更新）
+	watcher, err := config.NewWatcher()
 	if err != nil {
 		// 系统启动错误，直接退出
-		fmt.Fprintf(os.Stderr, "[FATAL] %v\n", errors.ErrConfigLoadFailed.WithError(err))
+		fmt.Fprintf(os.Stderr, "[FATAL] %v\n", errors.ErrConfigLoadFailed.WithError(err).WithDetail(err.Error()))
 		os.Exit(1)
 	}
+	cfg := watcher.Config()
 
 	// ==================== 第二阶段：初始化日志 ====================
 	// 日志模块依赖配置，用于记录应用运行状态
@@ -41,6 +51,27 @@ func main() {
 
 	// 记录应用启动日志
 	appLogger.Info("application starting", "name", cfg.App.Name, "env", cfg.App.Env, "version", "1.0.0")
+	appLogger.Info("config loaded", "files", watcher.Files())
+	// 每个配置项的来源（默认值、配置文件、环境变量、命令行参数），排查配置问题时开启 debug 日志查看
+	sources := make(map[string]string)
+	for key, source := range watcher.Sources() {
+		sources[key] = source.String()
+	}
+	appLogger.Debug("config sources", "sources", sources)
+
+	// 记录需要在退出时关闭的模块
+	// 按初始化顺序追加，关闭时逆序执行（后初始化的先关闭）
+	var closers []closer
+
+	// 初始化链路追踪（在数据库、Redis 之前，保证它们的 Span 能被导出）
+	if cfg.Middleware.Trace.Enabled {
+		tracer, err := tracing.New(cfg.Middleware.Trace, cfg.App.Name, cfg.App.Env)
+		if err != nil {
+			appLogger.Fatal("failed to initialize tracing", "error", err)
+		}
+		closers = append(closers, closer{name: "tracing", shutdown: tracer.Shutdown})
+		appLogger.Info("tracing initialized", "exporter", cfg.Middleware.Trace.Exporter)
+	}
 
 	// ==================== 第三阶段：初始化健康检查管理器 ====================
 	// 健康检查管理器需要在基础设施模块之前初始化
@@ -60,8 +91,13 @@ func main() {
 		if err != nil {
 			appLogger.Fatal("failed to initialize database", "error", errors.ErrDBConnectFailed.WithError(err))
 		}
-		defer dbMgr.Close()
+		closers = append(closers, closer{name: "database", close: dbMgr.Close})
 		appLogger.Info("database initialized", "count", len(cfg.Databases))
+
+		// 执行数据库迁移（开启了 migrations.auto_migrate 的数据库），在开始接收请求之前完成
+		if err := autoMigrate(cfg.Databases, dbMgr, appLogger); err != nil {
+			appLogger.Fatal("failed to migrate database", "error", errors.ErrDBMigrateFailed.WithError(err))
+		}
 	}
 
 	// 4.2 初始化 Redis（如果配置了）
@@ -72,10 +108,45 @@ func main() {
 		if err != nil {
 			appLogger.Fatal("failed to initialize redis", "error", errors.ErrRedisConnectFailed.WithError(err))
 		}
-		defer rdb.Close()
+		closers = append(closers, closer{name: "redis", close: rdb.Close})
 		appLogger.Info("redis initialized", "mode", cfg.Redis.Mode)
 	}
 
+	// 基础设施初始化完成后再登记健康检查的关闭
+	// 关闭时逆序执行，保证后台检查先于数据库、Redis 停止
+	closers = append(closers, closer{name: "health checks", close: healthMgr.Close})
+
+	// 4.3 初始化 JWT（如果配置了密钥）
+	// 配置了 Redis 时使用 Redis 存储已吊销的刷新令牌，多实例共享
+	var jwtMgr *middleware.JWT
+	if cfg.JWT.Secret != "" {
+		var store middleware.RevocationStore
+		if rdb != nil {
+			store = middleware.NewRedisRevocationStore(rdb)
+		}
+		var err error
+		jwtMgr, err = middleware.NewJWT(cfg.JWT, store)
+		if err != nil {
+			appLogger.Fatal("failed to initialize jwt", "error", err)
+		}
+		appLogger.Info("jwt initialized", "issuer", cfg.JWT.Issuer)
+	}
+
+	// 4.4 初始化监控指标（如果启用）
+	// 连接池和健康检查指标在拉取时读取，只需注册对应模块
+	var appMetrics *metrics.Metrics
+	if cfg.Metrics.Enabled {
+		appMetrics = metrics.New()
+		appMetrics.RegisterHealth(healthMgr)
+		if dbMgr != nil {
+			appMetrics.RegisterDatabases(dbMgr)
+		}
+		if rdb != nil {
+			appMetrics.RegisterRedis(rdb)
+		}
+		appLogger.Info("metrics initialized", "path", cfg.Metrics.Path, "port", cfg.Metrics.Port)
+	}
+
 	// ==================== 第五阶段：初始化 HTTP 服务器 ====================
 	// 设置 Gin 模式（根据环境决定）
 	if cfg.App.Env == "dev" {
@@ -91,46 +162,244 @@ func main() {
 	engine := gin.New()
 
 	// 注册自定义中间件（替换 Gin 默认中间件）
-	engine.Use(response.Recovery(appLogger))         // Panic 恢复（统一错误响应）
-	engine.Use(logger.GinLogger(appLogger))          // 请求日志
-	engine.Use(middleware.CORS(cfg.Middleware.CORS)) // CORS 跨域
-	// TODO: 实现其他中间件 (pkg/middleware)
-	// engine.Use(middleware.RateLimit(cfg.Middleware.RateLimit))  // 限流
-	// engine.Use(middleware.Trace(cfg.Middleware.Trace))  // 链路追踪
+	engine.Use(middleware.Trace(cfg.Middleware.Trace)) // 链路追踪（最先执行，后续日志和响应都带 TraceID）
+	if appMetrics != nil {
+		engine.Use(appMetrics.Middleware()) // 请求指标（在 Recovery 之前，能记录 panic 后的 500）
+	}
+	// CORS 和限流支持配置热更新，通过 Reloadable 注册
+	corsMW := middleware.NewReloadable(middleware.CORS(cfg.Middleware.CORS))
+	rateLimitMW := middleware.NewReloadable(middleware.RateLimit(cfg.Middleware.RateLimit, rdb, appLogger))
+
+	engine.Use(response.Recovery(appLogger))                                   // Panic 恢复（统一错误响应）
+	engine.Use(logger.GinLogger(appLogger))                                    // 请求日志
+	engine.Use(corsMW.Handler())                                               // CORS 跨域
+	engine.Use(rateLimitMW.Handler())                                          // 限流（配置了 Redis 时多实例共享计数）
+	engine.Use(middleware.ReadYourWrites(readYourWritesWindow(cfg.Databases))) // 读写一致性（写入后窗口期内读主库）
 
 	// 注册所有路由（使用新的路由注册方式）
 	router.Setup(engine, &router.RouterConfig{
 		Logger: appLogger,
 		DB:     dbMgr,
 		Redis:  rdb,
+		Health: healthMgr,
+		JWT:    jwtMgr,
 	})
 
+	// 注册监控指标端点
+	// 默认单独启动一个只提供指标的 HTTP 服务（只对内网暴露）；dev 环境端口配置为 0 时挂载在主服务上
+	if appMetrics != nil {
+		if cfg.Metrics.Port == 0 {
+			engine.GET(cfg.Metrics.Path, gin.WrapH(appMetrics.Handler()))
+		} else {
+			mux := http.NewServeMux()
+			mux.Handle(cfg.Metrics.Path, appMetrics.Handler())
+			metricsCfg := cfg.Server.HTTP
+			metricsCfg.Port = cfg.Metrics.Port
+			metricsSrv := startHTTPServer(mux, metricsCfg, appLogger)
+			closers = append(closers, closer{name: "metrics server", shutdown: metricsSrv.Shutdown})
+		}
+	}
+
+	// 监听配置变化（配置文件变化或收到 SIGHUP 时重新加载）
+	subscribeConfigChanges(watcher, appLogger, dbMgr, rdb, corsMW, rateLimitMW)
+	err = watcher.Start(func(source string, changed bool, err error) {
+		switch {
+		case err != nil && !changed:
+			appLogger.Error("config reload rejected, keeping current config", "source", source, "error", err)
+		case err != nil:
+			appLogger.Warn("config reloaded with errors", "source", source, "error", err)
+		case changed:
+			appLogger.Info("config reloaded", "source", source)
+		}
+	})
+	if err != nil {
+		appLogger.Warn("failed to watch config files, reload with SIGHUP instead", "error", err)
+	}
+	closers = append(closers, closer{name: "config watcher", close: watcher.Stop})
+
 	// ==================== 第六阶段：启动 HTTP 服务器 ====================
-	// 使用优雅关闭机制
-	srv := startHTTPServer(engine, cfg.Server.HTTP.Port, appLogger)
+	// 使用 http.Server 启动，支持超时配置和优雅关闭
+	srv := startHTTPServer(engine, cfg.Server.HTTP, appLogger)
 
 	// ==================== 第七阶段：等待退出信号 ====================
 	// 监听系统信号，实现优雅关闭
-	waitForShutdown(srv, appLogger)
+	waitForShutdown(srv, cfg.Server.Shutdown, healthMgr, closers, appLogger)
 
 	appLogger.Info("GoFast application stopped")
 }
 
-func startHTTPServer(router *gin.Engine, port int, log *logger.Logger) *gin.Engine {
-	addr := fmt.Sprintf(":%d", port)
-	log.Info("HTTP server starting", "addr", addr)
+// closer 需要在退出时关闭的模块
+type closer struct {
+	name     string                          // 模块名称（用于日志）
+	close    func() error                    // 关闭函数
+	shutdown func(ctx context.Context) error // 受关闭超时控制的关闭函数（如 http.Server.Shutdown），设置后代替 close
+}
+
+// subscribeConfigChanges 注册配置热更新的订阅者
+//
+// 初级工程师学习要点：
+// - 日志级别、CORS、限流、数据库、Redis 的配置变化会立即生效
+// - 监听端口、链路追踪、JWT 等在启动时就固定的配置，变化后只记录警告，需要重启才能生效
+// - 数据库、Redis 是在启动时按需创建的，运行时不能从无到有或从有到无
+func subscribeConfigChanges(watcher *config.Watcher, log *logger.Logger, dbMgr *database.Manager, rdb *redis.Redis, corsMW, rateLimitMW *middleware.Reloadable) {
+	watcher.Subscribe("logger", func(old, new *config.Config) error {
+		if old.Logger.Level != new.Logger.Level {
+			log.SetLevel(new.Logger.Level)
+			log.Info("log level changed", "from", old.Logger.Level, "to", new.Logger.Level)
+		}
+		return nil
+	})
+
+	watcher.Subscribe("cors", func(old, new *config.Config) error {
+		if !reflect.DeepEqual(old.Middleware.CORS, new.Middleware.CORS) {
+			corsMW.Swap(middleware.CORS(new.Middleware.CORS))
+			log.Info("cors config applied")
+		}
+		return nil
+	})
+
+	watcher.Subscribe("rate_limit", func(old, new *config.Config) error {
+		if !reflect.DeepEqual(old.Middleware.RateLimit, new.Middleware.RateLimit) {
+			rateLimitMW.Swap(middleware.RateLimit(new.Middleware.RateLimit, rdb, log))
+			log.Info("rate limit config applied")
+		}
+		return nil
+	})
+
+	watcher.Subscribe("databases", func(old, new *config.Config) error {
+		if reflect.DeepEqual(old.Databases, new.Databases) {
+			return nil
+		}
+		if dbMgr == nil {
+			log.Warn("databases were not configured at startup, restart required to apply")
+			return nil
+		}
+		return dbMgr.Reload(new.Databases)
+	})
+
+	watcher.Subscribe("redis", func(old, new *config.Config) error {
+		if reflect.DeepEqual(old.Redis, new.Redis) {
+			return nil
+		}
+		if rdb == nil || new.Redis.Mode == "" {
+			log.Warn("redis cannot be enabled or disabled at runtime, restart required to apply")
+			return nil
+		}
+		if err := rdb.Reload(new.Redis); err != nil {
+			return err
+		}
+		log.Info("redis reloaded", "name", new.Redis.Name)
+		return nil
+	})
+
+	watcher.Subscribe("restart_required", func(old, new *config.Config) error {
+		sections := map[string][2]any{
+			"app":              {old.App, new.App},
+			"server":           {old.Server, new.Server},
+			"health":           {old.Health, new.Health},
+			"jwt":              {old.JWT, new.JWT},
+			"metrics":          {old.Metrics, new.Metrics},
+			"middleware.trace": {old.Middleware.Trace, new.Middleware.Trace},
+		}
+		for name, values := range sections {
+			if !reflect.DeepEqual(values[0], values[1]) {
+				log.Warn("config change requires restart to take effect", "section", name)
+			}
+		}
+		return nil
+	})
+}
+
+// readYourWritesWindow 返回所有数据库中最大的读写一致性窗口
+//
+// 初级工程师学习要点：
+// - 中间件只负责记录写入时间，每个数据库仍按自己的窗口判断是否读主库
+// - Cookie 有效期取最大值，保证窗口最长的数据库也能跨请求生效
+func readYourWritesWindow(databases []config.DatabaseConfig) time.Duration {
+	var window time.Duration
+	for _, db := range databases {
+		window = max(window, db.Replication.ReadYourWritesWindow)
+	}
+	return window
+}
+
+// autoMigrate 为开启了 auto_migrate 的数据库执行未执行的迁移
+//
+// 初级工程师学习要点：
+// - 多个实例同时启动时只有一个执行迁移，其他实例等待锁，拿到锁后发现已经没有需要执行的迁移
+// - 热更新新增的数据库不会自动迁移，需要重启或运行 go run ./cmd/migrate up
+func autoMigrate(databases []config.DatabaseConfig, dbMgr *database.Manager, log *logger.Logger) error {
+	for _, dbCfg := range databases {
+		if !dbCfg.Migrations.AutoMigrate {
+			continue
+		}
+
+		m, err := migrate.Open(dbMgr.Get(dbCfg.Name), dbCfg)
+		if err != nil {
+			return fmt.Errorf("%s: %w", dbCfg.Name, err)
+		}
+		start := time.Now()
+		applied, err := m.Up(context.Background())
+		for _, migration := range applied {
+			log.Info("migration applied", "database", dbCfg.Name, "version", migration.Version, "name", migration.Name)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", dbCfg.Name, err)
+		}
+		log.Info("database migrated", "database", dbCfg.Name, "applied", len(applied), "elapsed", time.Since(start))
+	}
+	return nil
+}
+
+// startHTTPServer 创建并启动 HTTP 服务器
+//
+// 初级工程师学习要点：
+// - 使用 http.Server 而不是 engine.Run，才能调用 Shutdown 实现优雅关闭
+// - 超时配置可以防止慢客户端长时间占用连接
+// - 先同步监听端口，端口被占用时能立即发现并退出
+func startHTTPServer(handler http.Handler, cfg config.HTTPConfig, log *logger.Logger) *http.Server {
+	srv := &http.Server{
+		Addr:           net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		Handler:        handler,
+		ReadTimeout:    cfg.ReadTimeout,
+		WriteTimeout:   cfg.WriteTimeout,
+		MaxHeaderBytes: cfg.MaxHeaderBytes,
+	}
 
-	// 在 goroutine 中启动服务器
+	// 同步监听端口
+	ln, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		log.Fatal("failed to bind HTTP port", "addr", srv.Addr, "error", errors.ErrPortBindFailed.WithError(err))
+	}
+
+	log.Info("HTTP server starting", "addr", srv.Addr)
+
+	// 在 goroutine 中处理请求
 	go func() {
-		if err := router.Run(addr); err != nil {
+		// Shutdown 后 Serve 会返回 http.ErrServerClosed，这是正常退出
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Fatal("failed to start HTTP server", "error", errors.ErrServerStartFailed.WithError(err))
 		}
 	}()
 
-	return router
+	return srv
 }
 
-func waitForShutdown(router *gin.Engine, log *logger.Logger) {
+// waitForShutdown 等待退出信号并执行优雅关闭
+//
+// 关闭顺序：
+// 1. 标记服务正在关闭，就绪探针返回 503
+// 2. 等待 PreStopDelay，让负载均衡摘除流量
+// 3. 停止接收新请求，等待在途请求处理完成（http.Server.Shutdown）
+// 4. 按初始化顺序的逆序关闭各模块（健康检查 -> Redis -> 数据库 -> 链路追踪）
+// 5. 日志缓冲区由 main 中的 defer 最后刷新
+//
+// 初级工程师学习要点：
+// - 所有步骤完成后立即返回，不会空等超时
+// - 超时后强制退出，避免 Pod 卡在 Terminating 状态
+// - 超时时还没关闭完的模块不再等待：记录这些模块后返回，进程退出时由操作系统回收它们的连接
+// - 关闭期间再次收到信号会跳过摘流等待
+func waitForShutdown(srv *http.Server, cfg config.ShutdownConfig, healthMgr *health.Manager, closers []closer, log *logger.Logger) {
 	// 创建信号通道
 	quit := make(chan os.Signal, 1)
 
@@ -138,32 +407,73 @@ func waitForShutdown(router *gin.Engine, log *logger.Logger) {
 	// SIGINT: Ctrl+C
 	// SIGTERM: kill 命令（Kubernetes 默认使用）
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(quit)
 
 	// 阻塞等待信号
 	sig := <-quit
 	log.Info("received shutdown signal, shutting down gracefully", "signal", sig.String())
 
+	// 1. 就绪探针返回 503
+	healthMgr.MarkShuttingDown()
+
+	// 2. 等待负载均衡摘除流量
+	if cfg.PreStopDelay > 0 {
+		log.Info("waiting for traffic to drain", "pre_stop_delay", cfg.PreStopDelay.String())
+		select {
+		case <-time.After(cfg.PreStopDelay):
+		case sig := <-quit:
+			log.Warn("received second signal, skipping pre-stop delay", "signal", sig.String())
+		}
+	}
+
 	// 设置优雅关闭超时时间
 	// 这个时间应该：
 	// 1. 大于最长的请求处理时间
-	// 2. 小于 Kubernetes 的 terminationGracePeriodSeconds（默认 30 秒）
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	// 2. 与 PreStopDelay 之和小于 Kubernetes 的 terminationGracePeriodSeconds
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
 	defer cancel()
 
-	// TODO: 关闭各个模块
-	// 关闭顺序应该与初始化顺序相反：
-	// 1. 停止接收新请求（HTTP 服务器）
-	// 2. 等待正在处理的请求完成
-	// 3. 关闭数据库连接
-	// 4. 关闭 Redis 连接
-	// 5. 刷新日志缓冲区
+	// 3. 停止接收新请求，等待在途请求完成
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Warn("HTTP server shutdown incomplete, closing remaining connections", "error", err)
+		srv.Close()
+	} else {
+		log.Info("HTTP server stopped")
+	}
+
+	// 4. 逆序关闭各模块
+	var closing atomic.Int64 // 正在关闭的模块下标（超时时记录哪些模块没有关闭完）
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := len(closers) - 1; i >= 0; i-- {
+			closing.Store(int64(i))
 
-	// 等待上下文超时或所有资源释放完成
-	<-ctx.Done()
+			var err error
+			if c := closers[i]; c.shutdown != nil {
+				err = c.shutdown(ctx)
+			} else {
+				err = c.close()
+			}
+			if err != nil {
+				log.Warn("failed to close module", "module", closers[i].name, "error", err)
+				continue
+			}
+			log.Info("module closed", "module", closers[i].name)
+		}
+	}()
 
-	if ctx.Err() == context.DeadlineExceeded {
-		log.Warn("shutdown timeout, forcing exit")
-	} else {
+	// 等待所有模块关闭完成或超时
+	select {
+	case <-done:
 		log.Info("shutdown completed")
+	case <-ctx.Done():
+		// 关闭 goroutine 被放弃：main 返回后进程立即退出，剩下的模块不会再关闭，
+		// 在此之前它写的日志可能丢失（日志写入是并发安全的，不影响 main 中的 Sync）
+		var pending []string
+		for i := closing.Load(); i >= 0; i-- {
+			pending = append(pending, closers[i].name)
+		}
+		log.Warn("shutdown timeout, forcing exit without closing remaining modules", "modules", pending)
 	}
 }
@@ -17,8 +17,9 @@
 package config
 
 import (
+	"errors"
 	"fmt"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/spf13/pflag"
@@ -30,6 +31,7 @@ import (
 // 架构思路：
 // - 使用嵌套结构体组织配置，清晰易读
 // - 使用 mapstructure tag 支持 Viper 解析
+// - 使用 validate tag 声明单个字段的校验规则（见 validate）
 // - 使用指针类型表示可选配置（如 Slaves）
 //
 // 初级工程师学习要点：
@@ -45,111 +47,210 @@ type Config struct {
 	Health     HealthConfig     `mapstructure:"health"`
 	JWT        JWTConfig        `mapstructure:"jwt"`
 	Middleware MiddlewareConfig `mapstructure:"middleware"`
+	Metrics    MetricsConfig    `mapstructure:"metrics"`
+
+	ConfigProviders []ProviderConfig `mapstructure:"config_providers"` // 额外的配置来源（如配置中心），见 Provider
+
+	secrets map[string]bool // 值来自密钥引用的配置项（打印配置时脱敏）
 }
 
 // AppConfig 应用基础配置
 type AppConfig struct {
-	Name string `mapstructure:"name"` // 应用名称
-	Env  string `mapstructure:"env"`  // 运行环境: dev, test, prod
+	Name string `mapstructure:"name" validate:"required"`           // 应用名称
+	Env  string `mapstructure:"env" validate:"oneof=dev test prod"` // 运行环境: dev, test, prod
 }
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	HTTP HTTPConfig `mapstructure:"http"`
-	GRPC GRPCConfig `mapstructure:"grpc"`
+	HTTP     HTTPConfig     `mapstructure:"http"`
+	GRPC     GRPCConfig     `mapstructure:"grpc"`
+	Shutdown ShutdownConfig `mapstructure:"shutdown"`
 }
 
 // HTTPConfig HTTP 服务配置
 type HTTPConfig struct {
 	Host           string        `mapstructure:"host"`
-	Port           int           `mapstructure:"port"`
-	ReadTimeout    time.Duration `mapstructure:"read_timeout"`
-	WriteTimeout   time.Duration `mapstructure:"write_timeout"`
-	MaxHeaderBytes int           `mapstructure:"max_header_bytes"`
+	Port           int           `mapstructure:"port" validate:"min=1,max=65535"`
+	ReadTimeout    time.Duration `mapstructure:"read_timeout" validate:"gte=0"`
+	WriteTimeout   time.Duration `mapstructure:"write_timeout" validate:"gte=0"`
+	MaxHeaderBytes int           `mapstructure:"max_header_bytes" validate:"gte=0"`
 }
 
 // GRPCConfig gRPC 服务配置
 type GRPCConfig struct {
 	Host           string `mapstructure:"host"`
-	Port           int    `mapstructure:"port"`
-	MaxRecvMsgSize int    `mapstructure:"max_recv_msg_size"`
-	MaxSendMsgSize int    `mapstructure:"max_send_msg_size"`
+	Port           int    `mapstructure:"port" validate:"gte=0,lte=65535"`
+	MaxRecvMsgSize int    `mapstructure:"max_recv_msg_size" validate:"gte=0"`
+	MaxSendMsgSize int    `mapstructure:"max_send_msg_size" validate:"gte=0"`
+}
+
+// ShutdownConfig 优雅关闭配置
+//
+// 初级工程师学习要点：
+// - PreStopDelay：收到退出信号后，先让就绪探针返回 503，等待负载均衡摘除流量
+// - Timeout：摘流完成后，等待在途请求处理完毕并关闭各模块的最长时间
+// - 两者之和应小于 Kubernetes 的 terminationGracePeriodSeconds（默认 30 秒）
+type ShutdownConfig struct {
+	Timeout      time.Duration `mapstructure:"timeout" validate:"gt=0"`         // 优雅关闭超时时间
+	PreStopDelay time.Duration `mapstructure:"pre_stop_delay" validate:"gte=0"` // 摘流等待时间
 }
 
 // DatabaseConfig 数据库配置
+//
+// 初级工程师学习要点：
+// - 连接信息通过驱动的配置结构体组装，密码中的 @、/、空格等字符不需要转义
+// - dial_timeout 对应 MySQL 的 timeout、PostgreSQL 的 connect_timeout
+// - read_timeout、write_timeout 只对 MySQL 生效，PostgreSQL 可以用 params 设置 statement_timeout
+// - params 是驱动参数，如 MySQL 的 interpolateParams、sql_mode，PostgreSQL 的 search_path、application_name
+// - params、dsn 中已经设置的参数优先于上面的字段
 type DatabaseConfig struct {
-	Name            string             `mapstructure:"name"`
-	Type            string             `mapstructure:"type"`
-	MaxIdleConns    int                `mapstructure:"max_idle_conns"`
-	MaxOpenConns    int                `mapstructure:"max_open_conns"`
-	ConnMaxLifetime time.Duration      `mapstructure:"conn_max_lifetime"`
-	ConnMaxIdleTime time.Duration      `mapstructure:"conn_max_idle_time"`
-	DialTimeout     time.Duration      `mapstructure:"dial_timeout"`
-	ReadTimeout     time.Duration      `mapstructure:"read_timeout"`
-	WriteTimeout    time.Duration      `mapstructure:"write_timeout"`
-	LogLevel        string             `mapstructure:"log_level"`
-	SlowThreshold   time.Duration      `mapstructure:"slow_threshold"`
+	Name            string             `mapstructure:"name" validate:"required"`
+	Type            string             `mapstructure:"type" validate:"oneof=mysql postgres sqlite"`
+	MaxIdleConns    int                `mapstructure:"max_idle_conns" validate:"gte=0"`
+	MaxOpenConns    int                `mapstructure:"max_open_conns" validate:"gte=0"`
+	ConnMaxLifetime time.Duration      `mapstructure:"conn_max_lifetime" validate:"gte=0"`
+	ConnMaxIdleTime time.Duration      `mapstructure:"conn_max_idle_time" validate:"gte=0"`
+	DialTimeout     time.Duration      `mapstructure:"dial_timeout" validate:"gte=0"`
+	ReadTimeout     time.Duration      `mapstructure:"read_timeout" validate:"gte=0"`
+	WriteTimeout    time.Duration      `mapstructure:"write_timeout" validate:"gte=0"`
+	LogLevel        string             `mapstructure:"log_level" validate:"omitempty,oneof=silent error warn info"`
+	SlowThreshold   time.Duration      `mapstructure:"slow_threshold" validate:"gte=0"`
+	LoadBalance     string             `mapstructure:"load_balance" validate:"omitempty,oneof=round_robin weighted random least_in_use"`
 	Reload          ReloadConfig       `mapstructure:"reload"`
 	HealthCheck     HealthCheckConfig  `mapstructure:"health_check"`
+	Replication     ReplicationConfig  `mapstructure:"replication"`
+	Transaction     TransactionConfig  `mapstructure:"transaction"`
+	Params          map[string]string  `mapstructure:"params"` // 驱动参数（主库和从库共用），见 DatabaseConfig 说明
+	TLS             DBTLSConfig        `mapstructure:"tls"`
+	Migrations      MigrationConfig    `mapstructure:"migrations"`
 	Master          DBInstanceConfig   `mapstructure:"master"`
-	Slaves          []DBInstanceConfig `mapstructure:"slaves"`
+	Slaves          []DBInstanceConfig `mapstructure:"slaves" validate:"dive"`
 }
 
 // DBInstanceConfig 数据库实例配置
+//
+// 初级工程师学习要点：
+// - 设置 dsn 时直接使用 dsn 连接，忽略 host、port、username 等连接字段，适合驱动参数复杂的场景
+// - dsn 中通常包含密码，与 password 一样打印配置时脱敏，建议通过 ${file:...} 引用
 type DBInstanceConfig struct {
+	DSN       string `mapstructure:"dsn" secret:"true"` // 完整连接字符串（可选，优先于下面的连接字段）
 	Host      string `mapstructure:"host"`
-	Port      int    `mapstructure:"port"`
+	Port      int    `mapstructure:"port" validate:"gte=0,lte=65535"`
 	Username  string `mapstructure:"username"`
-	Password  string `mapstructure:"password"`
+	Password  string `mapstructure:"password" secret:"true"`
 	Database  string `mapstructure:"database"`
 	Charset   string `mapstructure:"charset"`
 	ParseTime bool   `mapstructure:"parse_time"`
 	Loc       string `mapstructure:"loc"`
-	SSLMode   string `mapstructure:"sslmode"` // PostgreSQL 专用
+	SSLMode   string `mapstructure:"sslmode"`                 // PostgreSQL 专用
+	Weight    int    `mapstructure:"weight" validate:"gte=0"` // 从库负载均衡权重（weighted、least_in_use 策略使用，默认 1）
+}
+
+// DBTLSConfig 数据库 TLS 配置
+//
+// 初级工程师学习要点：
+// - ca_file 用于验证服务端证书（自签名证书、云数据库的 CA）
+// - cert_file、key_file 用于双向认证（服务端要求客户端证书时）
+// - 启用后 PostgreSQL 的 sslmode 不再生效，不会降级为明文连接
+type DBTLSConfig struct {
+	Enabled            bool   `mapstructure:"enabled"`
+	CAFile             string `mapstructure:"ca_file"`                                    // CA 证书文件（为空时使用系统 CA）
+	CertFile           string `mapstructure:"cert_file" validate:"required_with=KeyFile"` // 客户端证书文件
+	KeyFile            string `mapstructure:"key_file" validate:"required_with=CertFile"` // 客户端私钥文件
+	ServerName         string `mapstructure:"server_name"`                                // 证书中的服务端名称（默认使用 host）
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`                       // 跳过服务端证书验证（仅限测试环境）
+}
+
+// MigrationConfig 数据库迁移配置
+//
+// 初级工程师学习要点：
+// - 迁移文件放在 dir 目录下，命名为 版本号_名称.up.sql 和 版本号_名称.down.sql（go run ./cmd/migrate create 生成）
+// - auto_migrate 为 true 时服务启动前自动执行未执行的迁移，多个实例同时启动时只有一个执行，其他等待
+// - 生产环境也可以关闭 auto_migrate，在发布流程中单独运行 go run ./cmd/migrate up
+type MigrationConfig struct {
+	Dir         string        `mapstructure:"dir"`                           // 迁移文件目录（默认 migrations/数据库名称）
+	Table       string        `mapstructure:"table"`                         // 记录迁移版本的表（默认 schema_migrations）
+	AutoMigrate bool          `mapstructure:"auto_migrate"`                  // 启动时是否自动执行迁移
+	LockTimeout time.Duration `mapstructure:"lock_timeout" validate:"gte=0"` // 等待其他实例完成迁移的最长时间（默认 5m）
 }
 
 // ReloadConfig 热更新配置
+//
+// 初级工程师学习要点：
+// - 热更新时会创建新的连接池，旧连接池保留一段时间后再关闭
+// - GracePeriod 是旧连接池至少保留的时间（默认 30s），期间仍在使用旧连接池的请求不受影响
+// - 宽限期结束后：ForceClose 为 true 时强制关闭旧连接池，否则等使用中的连接归还后再关闭
+// - CheckInterval 是检查旧连接池是否空闲的间隔（默认 1s）
 type ReloadConfig struct {
-	GracePeriod   time.Duration `mapstructure:"grace_period"`
+	GracePeriod   time.Duration `mapstructure:"grace_period" validate:"gte=0"`
 	ForceClose    bool          `mapstructure:"force_close"`
-	CheckInterval time.Duration `mapstructure:"check_interval"`
+	CheckInterval time.Duration `mapstructure:"check_interval" validate:"gte=0"`
 }
 
 // HealthCheckConfig 健康检查配置
+//
+// 初级工程师学习要点：
+// - Enabled 为 true 时由 health.Manager 在后台定时检查，探针读取缓存结果
+// - Enabled 为 false 时不做后台检查，每次探针请求实时检查
+// - Retries 是连续失败阈值，达到后才标记为不健康，避免偶发抖动导致摘流
+// - Criticality 为 non_critical 的组件（如缓存）失败时，整体状态为 degraded，仍然接收流量
 type HealthCheckConfig struct {
-	Enabled  bool          `mapstructure:"enabled"`
-	Interval time.Duration `mapstructure:"interval"`
-	Timeout  time.Duration `mapstructure:"timeout"`
-	Retries  int           `mapstructure:"retries"`
+	Enabled     bool          `mapstructure:"enabled"`                                                      // 是否启用后台定时检查
+	Interval    time.Duration `mapstructure:"interval" validate:"gte=0"`                                    // 检查间隔（默认 30s）
+	Timeout     time.Duration `mapstructure:"timeout" validate:"gte=0"`                                     // 单次检查超时（默认使用 health.timeout）
+	Retries     int           `mapstructure:"retries" validate:"gte=0"`                                     // 连续失败阈值（默认 1）
+	Criticality string        `mapstructure:"criticality" validate:"omitempty,oneof=critical non_critical"` // 关键程度：critical（默认）、non_critical
+}
+
+// ReplicationConfig 主从复制配置
+//
+// 初级工程师学习要点：
+// - 从库的数据通过复制同步，存在延迟（lag），刚写入主库的数据在从库上可能还读不到
+// - MaxLag：从库延迟超过该值时暂时不参与读请求（0 表示不检测延迟）
+// - ReadYourWritesWindow：同一请求/会话写入后，该时间窗口内的读请求走主库（0 表示不启用）
+type ReplicationConfig struct {
+	MaxLag               time.Duration `mapstructure:"max_lag" validate:"gte=0"`
+	ReadYourWritesWindow time.Duration `mapstructure:"read_your_writes_window" validate:"gte=0"`
+}
+
+// TransactionConfig 事务配置
+//
+// 初级工程师学习要点：
+// - 死锁、序列化失败是并发事务的正常现象，数据库会回滚其中一个事务，重试通常就能成功
+// - MaxAttempts 是最大执行次数（含第一次），1 表示不重试，0 表示使用默认值 3
+// - RetryBackoff 是第一次重试前的等待时间，之后每次翻倍（默认 20ms）
+type TransactionConfig struct {
+	MaxAttempts  int           `mapstructure:"max_attempts" validate:"gte=0"`
+	RetryBackoff time.Duration `mapstructure:"retry_backoff" validate:"gte=0"`
 }
 
 // RedisConfig Redis 配置
 type RedisConfig struct {
-	Name               string            `mapstructure:"name"`
-	Mode               string            `mapstructure:"mode"`
+	Name               string            `mapstructure:"name" validate:"required"`
+	Mode               string            `mapstructure:"mode" validate:"oneof=standalone sentinel cluster"`
 	Addr               string            `mapstructure:"addr"`
-	MasterName         string            `mapstructure:"master_name"`    // 哨兵模式：主节点名称
-	SentinelAddrs      []string          `mapstructure:"sentinel_addrs"` // 哨兵模式：哨兵地址列表
-	ClusterAddrs       []string          `mapstructure:"cluster_addrs"`  // 集群模式：集群节点地址列表
-	Password           string            `mapstructure:"password"`
-	DB                 int               `mapstructure:"db"`
-	PoolSize           int               `mapstructure:"pool_size"`
-	MinIdleConns       int               `mapstructure:"min_idle_conns"`
-	MaxRetries         int               `mapstructure:"max_retries"`
-	DialTimeout        time.Duration     `mapstructure:"dial_timeout"`
+	MasterName         string            `mapstructure:"master_name"`                                  // 哨兵模式：主节点名称
+	SentinelAddrs      []string          `mapstructure:"sentinel_addrs" validate:"dive,hostname_port"` // 哨兵模式：哨兵地址列表
+	ClusterAddrs       []string          `mapstructure:"cluster_addrs" validate:"dive,hostname_port"`  // 集群模式：集群节点地址列表
+	Password           string            `mapstructure:"password" secret:"true"`
+	DB                 int               `mapstructure:"db" validate:"gte=0"`
+	PoolSize           int               `mapstructure:"pool_size" validate:"gte=0"`
+	MinIdleConns       int               `mapstructure:"min_idle_conns" validate:"gte=0"`
+	MaxRetries         int               `mapstructure:"max_retries" validate:"gte=-1"`
+	DialTimeout        time.Duration     `mapstructure:"dial_timeout" validate:"gte=0"`
 	ReadTimeout        time.Duration     `mapstructure:"read_timeout"`
 	WriteTimeout       time.Duration     `mapstructure:"write_timeout"`
-	PoolTimeout        time.Duration     `mapstructure:"pool_timeout"`
-	IdleTimeout        time.Duration     `mapstructure:"idle_timeout"`
-	IdleCheckFrequency time.Duration     `mapstructure:"idle_check_frequency"`
+	PoolTimeout        time.Duration     `mapstructure:"pool_timeout" validate:"gte=0"`
+	IdleTimeout        time.Duration     `mapstructure:"idle_timeout" validate:"gte=0"`
+	IdleCheckFrequency time.Duration     `mapstructure:"idle_check_frequency" validate:"gte=0"`
 	Reload             ReloadConfig      `mapstructure:"reload"`
 	HealthCheck        HealthCheckConfig `mapstructure:"health_check"`
 }
 
 // LoggerConfig 日志配置
 type LoggerConfig struct {
-	Level            string              `mapstructure:"level"`
-	Format           string              `mapstructure:"format"`
+	Level            string              `mapstructure:"level" validate:"oneof=debug info warn error fatal"`
+	Format           string              `mapstructure:"format" validate:"oneof=json console"`
 	Console          LoggerConsoleConfig `mapstructure:"console"`
 	File             LoggerFileConfig    `mapstructure:"file"`
 	EnableCaller     bool                `mapstructure:"enable_caller"`
@@ -164,25 +265,25 @@ type LoggerConsoleConfig struct {
 // LoggerFileConfig 日志文件配置
 type LoggerFileConfig struct {
 	Enabled    bool   `mapstructure:"enabled"` // 是否启用文件输出
-	Filename   string `mapstructure:"filename"`
-	MaxSize    int    `mapstructure:"max_size"`
-	MaxBackups int    `mapstructure:"max_backups"`
-	MaxAge     int    `mapstructure:"max_age"`
+	Filename   string `mapstructure:"filename" validate:"required_if=Enabled true"`
+	MaxSize    int    `mapstructure:"max_size" validate:"gte=0"`
+	MaxBackups int    `mapstructure:"max_backups" validate:"gte=0"`
+	MaxAge     int    `mapstructure:"max_age" validate:"gte=0"`
 	Compress   bool   `mapstructure:"compress"`
 }
 
 // HealthConfig 健康检查模块配置
 type HealthConfig struct {
-	Timeout  time.Duration `mapstructure:"timeout"`
+	Timeout  time.Duration `mapstructure:"timeout" validate:"gt=0"`
 	Detailed bool          `mapstructure:"detailed"`
 }
 
 // JWTConfig JWT 配置
 type JWTConfig struct {
-	Secret        string `mapstructure:"secret"`
-	Expire        int    `mapstructure:"expire"`
-	RefreshExpire int    `mapstructure:"refresh_expire"`
-	Issuer        string `mapstructure:"issuer"`
+	Secret        string `mapstructure:"secret" secret:"true"`
+	Expire        int    `mapstructure:"expire" validate:"gt=0"`
+	RefreshExpire int    `mapstructure:"refresh_expire" validate:"gtfield=Expire"`
+	Issuer        string `mapstructure:"issuer" validate:"required"`
 }
 
 // MiddlewareConfig 中间件配置
@@ -199,90 +300,196 @@ type MiddlewareConfig struct {
 // - 浏览器安全机制，限制跨域请求
 // - 通过 HTTP 响应头控制跨域行为
 type CORSConfig struct {
-	Enabled          bool          `mapstructure:"enabled"`           // 是否启用 CORS
-	AllowOrigins     []string      `mapstructure:"allow_origins"`     // 允许的源列表
-	AllowMethods     []string      `mapstructure:"allow_methods"`     // 允许的 HTTP 方法
-	AllowHeaders     []string      `mapstructure:"allow_headers"`     // 允许的请求头
-	ExposeHeaders    []string      `mapstructure:"expose_headers"`    // 暴露给客户端的响应头
-	AllowCredentials bool          `mapstructure:"allow_credentials"` // 是否允许携带认证信息（Cookie）
-	MaxAge           time.Duration `mapstructure:"max_age"`           // 预检请求缓存时间
-	AllowWildcard    bool          `mapstructure:"allow_wildcard"`    // 是否允许通配符（如 https://*.example.com）
+	Enabled          bool          `mapstructure:"enabled"`                  // 是否启用 CORS
+	AllowOrigins     []string      `mapstructure:"allow_origins"`            // 允许的源列表
+	AllowMethods     []string      `mapstructure:"allow_methods"`            // 允许的 HTTP 方法
+	AllowHeaders     []string      `mapstructure:"allow_headers"`            // 允许的请求头
+	ExposeHeaders    []string      `mapstructure:"expose_headers"`           // 暴露给客户端的响应头
+	AllowCredentials bool          `mapstructure:"allow_credentials"`        // 是否允许携带认证信息（Cookie）
+	MaxAge           time.Duration `mapstructure:"max_age" validate:"gte=0"` // 预检请求缓存时间
+	AllowWildcard    bool          `mapstructure:"allow_wildcard"`           // 是否允许通配符（如 https://*.example.com）
 }
 
 // RateLimitConfig 限流配置
+//
+// 初级工程师学习要点：
+// - Algorithm 限流算法：fixed_window（固定窗口）、sliding_window（滑动窗口）、token_bucket（令牌桶）
+// - KeyBy 限流维度：ip（客户端 IP）、subject（JWT 认证主体）、route（路由）
+// - Routes 按路由前缀覆盖全局规则，未设置的字段继承全局配置
+// - 配置了 Redis 时计数存储在 Redis（多实例共享），否则存储在进程内
 type RateLimitConfig struct {
-	Enabled  bool          `mapstructure:"enabled"`
-	Requests int           `mapstructure:"requests"`
-	Window   time.Duration `mapstructure:"window"`
+	Enabled   bool                   `mapstructure:"enabled"`
+	Requests  int                    `mapstructure:"requests" validate:"gt=0"`                                            // 时间窗口内允许的请求数
+	Window    time.Duration          `mapstructure:"window" validate:"gt=0"`                                              // 时间窗口大小
+	Algorithm string                 `mapstructure:"algorithm" validate:"oneof=fixed_window sliding_window token_bucket"` // 限流算法
+	KeyBy     string                 `mapstructure:"key_by" validate:"oneof=ip subject route"`                            // 限流维度
+	Burst     int                    `mapstructure:"burst" validate:"gte=0"`                                              // 令牌桶容量（默认等于 requests）
+	Skip      []string               `mapstructure:"skip"`                                                                // 不限流的路径前缀
+	Routes    []RateLimitRouteConfig `mapstructure:"routes" validate:"dive"`                                              // 按路由前缀覆盖
+}
+
+// RateLimitRouteConfig 路由级限流配置
+type RateLimitRouteConfig struct {
+	Prefix    string        `mapstructure:"prefix" validate:"startswith=/"` // 路由前缀，如 /api/v1/auth
+	Requests  int           `mapstructure:"requests" validate:"gte=0"`
+	Window    time.Duration `mapstructure:"window" validate:"gte=0"`
+	Algorithm string        `mapstructure:"algorithm" validate:"omitempty,oneof=fixed_window sliding_window token_bucket"`
+	KeyBy     string        `mapstructure:"key_by" validate:"omitempty,oneof=ip subject route"`
+	Burst     int           `mapstructure:"burst" validate:"gte=0"`
 }
 
 // TraceConfig 链路追踪配置
+//
+// 初级工程师学习要点：
+// - Header 是自定义 TraceID 请求头，同时支持 W3C 标准的 traceparent/tracestate
+// - Exporter 决定 Span 发送到哪里：none（不导出）、stdout、otlp
+// - SampleRatio 采样率，0~1 之间，1 表示全部采样
 type TraceConfig struct {
+	Enabled     bool    `mapstructure:"enabled"`
+	Header      string  `mapstructure:"header" validate:"required"`
+	Exporter    string  `mapstructure:"exporter" validate:"oneof=none stdout otlp"`    // Span 导出方式
+	Endpoint    string  `mapstructure:"endpoint" validate:"required_if=Exporter otlp"` // OTLP 采集器地址，如 otel-collector:4318
+	Insecure    bool    `mapstructure:"insecure"`                                      // OTLP 是否使用明文 HTTP
+	SampleRatio float64 `mapstructure:"sample_ratio" validate:"gte=0,lte=1"`           // 采样率
+}
+
+// MetricsConfig 监控指标配置
+//
+// 初级工程师学习要点：
+// - 指标以 Prometheus 文本格式暴露，由 Prometheus 定期拉取（Pull 模式）
+// - 默认单独监听 9100 端口，只对内网暴露，不会随业务接口一起公开
+// - Port 为 0 时挂载在主 HTTP 服务上，只允许在 dev 环境使用
+type MetricsConfig struct {
 	Enabled bool   `mapstructure:"enabled"`
-	Header  string `mapstructure:"header"`
+	Path    string `mapstructure:"path" validate:"startswith=/"`    // 指标路径，默认 /metrics
+	Port    int    `mapstructure:"port" validate:"gte=0,lte=65535"` // 独立监听端口，默认 9100（0 表示使用主服务端口，仅 dev）
 }
 
 // Load 加载配置
 //
 // 架构思路：
 // 1. 设置默认值（保证即使没有配置文件也能运行）
-// 2. 读取配置文件
-// 3. 读取环境变量（覆盖配置文件）
-// 4. 读取命令行参数（最高优先级）
-// 5. 验证配置
+// 2. 读取配置文件（基础文件 + 环境覆盖文件 + 本地覆盖文件，依次合并）
+// 3. 合并配置提供者（config_providers，如配置中心）的配置
+// 4. 读取环境变量（覆盖配置文件和配置提供者）
+// 5. 读取命令行参数（最高优先级）
+// 6. 验证配置
 //
 // 初级工程师学习要点：
 // - 理解配置优先级的重要性
 // - 掌握 Viper 的基本用法
 // - 学习错误处理的最佳实践
-//
-// 高级工程师思考：
-// - 如何支持配置热更新？
-// - 如何处理敏感信息（密码、密钥）？
-// - 如何支持配置中心（如 Consul、etcd）？
+// - 需要配置热更新或查看配置来源时使用 NewWatcher
+// - 密码、密钥可以写成 ${file:...}、${env:...} 引用或 enc: 加密值，加载时自动解析（见 resolveSecrets）
+// - 接入 Consul、etcd 等配置中心时实现 Provider 接口并用 RegisterProvider 注册
 func Load() (*Config, error) {
+	s, err := load(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.config, nil
+}
+
+// snapshot 一次加载的结果
+type snapshot struct {
+	config  *Config
+	files   []string // 实际读取的配置文件（按合并顺序）
+	watch   []string // 需要监听的配置文件（包括还不存在的覆盖文件）
+	sources Sources  // 每个配置项的来源
+
+	providers []*providerEntry // 配置提供者（热更新时配置不变的复用）
+}
+
+// load 读取所有配置来源，解析并验证
+//
+// 初级工程师学习要点：
+// - 每次调用都创建新的 Viper 实例，热更新时重新读取不会和正在使用的实例互相影响
+// - Viper 的优先级与设置顺序无关：参数 > 环境变量 > 配置文件 > 默认值
+func load(prev *snapshot) (*snapshot, error) {
 	v := viper.New()
 
 	// 第一步：设置默认值
 	setDefaults(v)
 
-	// 第二步：设置配置文件
-	v.SetConfigName("config")       // 配置文件名（不含扩展名）
-	v.SetConfigType("yaml")         // 配置文件类型
-	v.AddConfigPath(".")            // 当前目录
-	v.AddConfigPath("./config")     // config 目录
-	v.AddConfigPath("/etc/gofast/") // 系统配置目录
-
-	// 第三步：读取配置文件
-	if err := v.ReadInConfig(); err != nil {
-		// 配置文件不存在不是致命错误，使用默认值即可
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			return nil, fmt.Errorf("failed to read config file: %w", err)
-		}
-	}
-
-	// 第四步：设置环境变量
+	// 第二步：设置环境变量
 	// 环境变量前缀为 GOFAST_
 	// 例如：GOFAST_SERVER_HTTP_PORT=8080
-	v.SetEnvPrefix("GOFAST")
-	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	// 需要在读取覆盖文件之前设置，app.env 可以来自环境变量
+	v.SetEnvPrefix(envPrefix)
+	v.SetEnvKeyReplacer(envKeyReplacer)
 	v.AutomaticEnv()
+	bindEnvs(v)
 
-	// 第五步：绑定命令行参数
+	// 第三步：绑定命令行参数（--env 同样决定读取哪个覆盖文件）
 	bindFlags(v)
 
-	// 第六步：解析配置到结构体
+	// 第四步：读取配置文件
+	files, watch, err := readConfigFiles(v)
+	if err != nil {
+		return nil, err
+	}
+
+	// 合并配置提供者（配置中心等）的配置，只有本地配置文件中的 config_providers 生效
+	var providerConfigs []ProviderConfig
+	if err := v.UnmarshalKey("config_providers", &providerConfigs, decodeHook); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config_providers: %w", err)
+	}
+	var prevProviders []*providerEntry
+	if prev != nil {
+		prevProviders = prev.providers
+	}
+	providers, err := openProviders(providerConfigs, prevProviders)
+	if err != nil {
+		return nil, err
+	}
+	providerValues, err := fetchProviders(v, providers)
+	if err != nil {
+		return nil, err
+	}
+
+	// 数组中的配置项（如 databases[0].master.password）需要在读取配置文件和配置提供者之后覆盖，才能按 name 查找
+	listEnvs, err := applyListEnvs(v)
+	if err != nil {
+		return nil, err
+	}
+
+	// 第五步：解析配置到结构体
 	var cfg Config
-	if err := v.Unmarshal(&cfg); err != nil {
+	if err := v.Unmarshal(&cfg, decodeHook); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
-	// 第七步：验证配置
+	// 解析密钥引用（${file:...}、${env:...}、enc:...），密钥文件变化时同样触发热更新
+	secretFiles, err := resolveSecrets(&cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve secret: %w", err)
+	}
+
+	// 第六步：验证配置（严格模式下同时检查配置文件中的未知配置项）
+	layers := append(fileLayers(files), providerLayers(providers, providerValues)...)
+	var errs ValidationErrors
+	if strictFlag() {
+		errs = unknownKeys(layers)
+	}
 	if err := validate(&cfg); err != nil {
-		return nil, fmt.Errorf("config validation failed: %w", err)
+		var fieldErrs ValidationErrors
+		if !errors.As(err, &fieldErrs) {
+			return nil, fmt.Errorf("config validation failed: %w", err)
+		}
+		errs = append(errs, fieldErrs...)
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("config validation failed: %w", errs)
 	}
 
-	return &cfg, nil
+	return &snapshot{
+		config:    &cfg,
+		files:     files,
+		watch:     append(watch, secretFiles...),
+		sources:   collectSources(v, layers, listEnvs),
+		providers: providers,
+	}, nil
 }
 
 // setDefaults 设置默认配置
@@ -307,6 +514,10 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("server.http.write_timeout", "60s")
 	v.SetDefault("server.http.max_header_bytes", 1048576)
 
+	// 优雅关闭配置
+	v.SetDefault("server.shutdown.timeout", "30s")
+	v.SetDefault("server.shutdown.pre_stop_delay", "0s")
+
 	// gRPC 服务配置
 	v.SetDefault("server.grpc.host", "0.0.0.0")
 	v.SetDefault("server.grpc.port", 9090)
@@ -340,9 +551,24 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("middleware.cors.max_age", "12h")
 	v.SetDefault("middleware.cors.allow_wildcard", false)
 
+	// 限流配置
+	v.SetDefault("middleware.rate_limit.enabled", false)
+	v.SetDefault("middleware.rate_limit.requests", 100)
+	v.SetDefault("middleware.rate_limit.window", "1m")
+	v.SetDefault("middleware.rate_limit.algorithm", "sliding_window")
+	v.SetDefault("middleware.rate_limit.key_by", "ip")
+	v.SetDefault("middleware.rate_limit.skip", []string{"/health", "/metrics"})
+
 	// 链路追踪配置
 	v.SetDefault("middleware.trace.enabled", true)
 	v.SetDefault("middleware.trace.header", "X-Trace-ID")
+	v.SetDefault("middleware.trace.exporter", "none")
+	v.SetDefault("middleware.trace.sample_ratio", 1.0)
+
+	// 监控指标配置
+	v.SetDefault("metrics.enabled", true)
+	v.SetDefault("metrics.path", "/metrics")
+	v.SetDefault("metrics.port", 9100)
 }
 
 // bindFlags 绑定命令行参数
@@ -354,138 +580,44 @@ func setDefaults(v *viper.Viper) {
 // 初级工程师学习要点：
 // - 理解命令行参数的使用场景
 // - 掌握 pflag 库的基本用法
+// - 参数只定义和解析一次（重复定义会 panic），热更新时只重新绑定
+// - 参数绑定到对应的配置项（如 --port 对应 server.http.port），--config、--strict 不是配置项，单独读取
 func bindFlags(v *viper.Viper) {
-	pflag.String("config", "", "配置文件路径")
-	pflag.String("env", "", "运行环境 (dev/test/prod)")
-	pflag.Int("port", 0, "HTTP 服务端口")
-
-	pflag.Parse()
-	v.BindPFlags(pflag.CommandLine)
-}
-
-// validate 验证配置
-//
-// 架构思路：
-// - 在应用启动时验证配置，快速失败（Fail Fast）
-// - 避免运行时才发现配置错误
-//
-// 初级工程师学习要点：
-// - 理解配置验证的重要性
-// - 掌握基本的数据验证方法
-//
-// 高级工程师思考：
-// - 如何使用验证库（如 validator）简化验证逻辑？
-// - 如何提供更友好的错误提示？
-func validate(cfg *Config) error {
-	// 验证应用配置
-	if cfg.App.Name == "" {
-		return fmt.Errorf("app.name is required")
-	}
-
-	if cfg.App.Env != "dev" && cfg.App.Env != "test" && cfg.App.Env != "prod" {
-		return fmt.Errorf("app.env must be one of: dev, test, prod")
-	}
-
-	// 验证 HTTP 服务配置
-	if cfg.Server.HTTP.Port <= 0 || cfg.Server.HTTP.Port > 65535 {
-		return fmt.Errorf("server.http.port must be between 1 and 65535")
-	}
-
-	// 验证数据库配置
-	if err := validateDatabases(cfg.Databases); err != nil {
-		return err
-	}
+	parseFlags.Do(func() {
+		pflag.String("config", "", "配置文件路径")
+		pflag.String("env", "", "运行环境 (dev/test/prod)")
+		pflag.Int("port", 0, "HTTP 服务端口")
+		pflag.Bool("strict", false, "严格模式：配置文件中有未知配置项时报错")
 
-	// 验证 Redis 配置
-	if err := validateRedis(cfg.Redis); err != nil {
-		return err
-	}
+		pflag.Parse()
+	})
 
-	// 验证 CORS 配置
-	if err := validateCORS(cfg.Middleware.CORS); err != nil {
-		return err
+	for key, name := range flagKeys {
+		v.BindPFlag(key, pflag.Lookup(name))
 	}
-
-	return nil
 }
 
-// validateDatabases 验证数据库配置
-//
-// 初级工程师学习要点：
-// - 检查每个数据库实例的 name 字段是否存在
-// - 检查 name 是否重复（使用 map 记录已出现的名称）
-func validateDatabases(databases []DatabaseConfig) error {
-	if len(databases) == 0 {
-		return nil // 没有配置数据库不是错误
-	}
-
-	// 使用 map 检查 name 重复
-	names := make(map[string]bool)
-
-	for i, db := range databases {
-		// 检查 name 是否为空
-		if db.Name == "" {
-			return fmt.Errorf("databases[%d].name is required", i)
-		}
-
-		// 检查 name 是否重复
-		if names[db.Name] {
-			return fmt.Errorf("databases[%d].name '%s' is duplicated", i, db.Name)
-		}
-		names[db.Name] = true
-
-		// 检查数据库类型
-		if db.Type != "mysql" && db.Type != "postgres" && db.Type != "sqlite" {
-			return fmt.Errorf("databases[%d].type must be one of: mysql, postgres, sqlite", i)
-		}
-	}
-
-	return nil
+// flagKeys 配置项与命令行参数的对应关系
+var flagKeys = map[string]string{
+	"app.env":          "env",
+	"server.http.port": "port",
 }
 
-// validateRedis 验证 Redis 配置
-//
-// 初级工程师学习要点：
-// - Redis 配置是单个实例，不是数组
-// - 如果配置了 Redis（mode 不为空），则 name 必填
-func validateRedis(redis RedisConfig) error {
-	// 如果没有配置 Redis，跳过验证
-	if redis.Mode == "" {
-		return nil
-	}
-
-	// 检查 name 是否为空
-	if redis.Name == "" {
-		return fmt.Errorf("redis.name is required")
+// configFlag 返回 --config 参数指定的配置文件路径
+func configFlag() string {
+	if f := pflag.Lookup("config"); f != nil {
+		return f.Value.String()
 	}
-
-	// 检查 mode 是否有效
-	if redis.Mode != "standalone" && redis.Mode != "sentinel" && redis.Mode != "cluster" {
-		return fmt.Errorf("redis.mode must be one of: standalone, sentinel, cluster")
-	}
-
-	return nil
+	return ""
 }
 
-// validateCORS 验证 CORS 配置
-//
-// 初级工程师学习要点：
-// - 当 AllowCredentials = true 时，AllowOrigins 不能包含 "*"
-// - 这是浏览器的安全限制，防止 CSRF 攻击
-func validateCORS(cors CORSConfig) error {
-	// 如果未启用，跳过验证
-	if !cors.Enabled {
-		return nil
+// strictFlag 返回是否开启了严格模式（--strict）
+func strictFlag() bool {
+	if f := pflag.Lookup("strict"); f != nil {
+		return f.Value.String() == "true"
 	}
-
-	// 检查 AllowCredentials 和 AllowOrigins 的组合
-	if cors.AllowCredentials {
-		for _, origin := range cors.AllowOrigins {
-			if origin == "*" {
-				return fmt.Errorf("middleware.cors: cannot use allow_credentials with wildcard origin '*'")
-			}
-		}
-	}
-
-	return nil
+	return false
 }
+
+// parseFlags 保证命令行参数只定义和解析一次
+var parseFlags sync.Once
@@ -0,0 +1,302 @@
+// Package config 环境变量覆盖
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/viper"
+)
+
+// bindEnvs 为所有配置项绑定环境变量
+//
+// 初级工程师学习要点：
+// - AutomaticEnv 只对 Viper 已知的配置项生效（有默认值或出现在配置文件中）
+// - 遍历 Config 结构体把每个配置项都绑定一次，配置文件中没有写的配置项也能用环境变量设置
+// - 例如 GOFAST_REDIS_PASSWORD、GOFAST_REDIS_SENTINEL_ADDRS=10.0.0.1:26379,10.0.0.2:26379
+// - 结构体数组（如 databases）不在这里处理，见 applyListEnvs
+func bindEnvs(v *viper.Viper) {
+	walkKeys(reflect.TypeOf(Config{}), "", func(key string) {
+		v.BindEnv(key)
+	})
+}
+
+// walkKeys 遍历结构体的所有配置项（跳过结构体数组）
+func walkKeys(t reflect.Type, prefix string, fn func(key string)) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("mapstructure")
+		if tag == "" || tag == "-" {
+			continue
+		}
+
+		key := tag
+		if prefix != "" {
+			key = prefix + "." + tag
+		}
+
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			walkKeys(field.Type, key, fn)
+		case isStructSlice(field.Type):
+			// 结构体数组由 applyListEnvs 处理
+		default:
+			fn(key)
+		}
+	}
+}
+
+// isStructSlice 判断类型是否为结构体数组
+func isStructSlice(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Struct
+}
+
+// applyListEnvs 使用环境变量覆盖结构体数组中的配置项
+//
+// 架构思路：
+// - 按下标：GOFAST_DATABASES_0_MASTER_PASSWORD -> databases[0].master.password
+// - 按名称：GOFAST_DATABASES_ORDERS_MASTER_PASSWORD -> name 为 orders 的数据库的 master.password
+// - 嵌套数组按下标：GOFAST_DATABASES_ORDERS_SLAVES_1_HOST -> slaves[1].host
+// - 修改后的整个数组通过 v.Set 写回（数组没有绑定命令行参数，不影响优先级）
+//
+// 初级工程师学习要点：
+// - 环境变量名只能包含大写字母、数字和下划线，名称中的 - 和 . 用 _ 代替（order-db -> ORDER_DB）
+// - 下标超出数组长度时会补齐，可以完全通过环境变量定义一个数据库
+// - 按名称找不到对应元素或配置项不存在时返回错误，避免拼写错误导致密钥没有生效
+//
+// 返回值：被覆盖的数组配置项 -> 生效的环境变量名（用于查看配置来源）
+func applyListEnvs(v *viper.Viper) (map[string][]string, error) {
+	var names []string
+	for _, kv := range os.Environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		if value != "" && strings.HasPrefix(name, envPrefix+"_") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	lists := make(map[string]reflect.Type)
+	walkLists(reflect.TypeOf(Config{}), "", lists)
+
+	applied := make(map[string][]string)
+	values := make(map[string][]any)
+	for _, name := range names {
+		tokens := strings.Split(strings.ToLower(strings.TrimPrefix(name, envPrefix+"_")), "_")
+
+		for key, elem := range lists {
+			keyTokens := strings.Split(envKeyReplacer.Replace(key), "_")
+			if !hasTokenPrefix(tokens, keyTokens) || len(tokens) == len(keyTokens) {
+				continue
+			}
+
+			list, ok := values[key]
+			if !ok {
+				list = toList(v.Get(key))
+			}
+
+			list, err := setListValue(list, elem, tokens[len(keyTokens):], os.Getenv(name))
+			if err != nil {
+				return nil, fmt.Errorf("invalid environment variable %s: %w", name, err)
+			}
+
+			values[key] = list
+			applied[key] = append(applied[key], name)
+		}
+	}
+
+	for key, list := range values {
+		v.Set(key, list)
+	}
+
+	return applied, nil
+}
+
+// walkLists 查找所有顶层结构体数组（嵌套在数组元素中的数组由 setListValue 处理）
+func walkLists(t reflect.Type, prefix string, lists map[string]reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("mapstructure")
+		if tag == "" || tag == "-" {
+			continue
+		}
+
+		key := tag
+		if prefix != "" {
+			key = prefix + "." + tag
+		}
+
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			walkLists(field.Type, key, lists)
+		case isStructSlice(field.Type):
+			lists[key] = field.Type.Elem()
+		}
+	}
+}
+
+// setListValue 按下标或名称找到数组元素，并设置元素中的配置项
+func setListValue(list []any, elem reflect.Type, tokens []string, value string) ([]any, error) {
+	index, rest, err := selectElement(list, elem, tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	for len(list) <= index {
+		list = append(list, map[string]any{})
+	}
+
+	item, ok := list[index].(map[string]any)
+	if !ok {
+		item = toMap(list[index])
+	}
+	if err := setStructValue(item, elem, rest, value); err != nil {
+		return nil, err
+	}
+	list[index] = item
+
+	return list, nil
+}
+
+// selectElement 解析数组元素的下标（数字下标或 name 字段）
+func selectElement(list []any, elem reflect.Type, tokens []string) (int, []string, error) {
+	if index, err := strconv.Atoi(tokens[0]); err == nil && index >= 0 {
+		return index, tokens[1:], nil
+	}
+
+	if _, ok := elem.FieldByName("Name"); !ok {
+		return 0, nil, fmt.Errorf("%q is not a valid index", tokens[0])
+	}
+
+	// 名称可能包含下划线，取能匹配的最长名称
+	best, bestLen := -1, 0
+	for i, item := range list {
+		name, _ := toMap(item)["name"].(string)
+		nameTokens := strings.Split(strings.NewReplacer("-", "_", ".", "_").Replace(strings.ToLower(name)), "_")
+		if name != "" && len(nameTokens) > bestLen && hasTokenPrefix(tokens, nameTokens) {
+			best, bestLen = i, len(nameTokens)
+		}
+	}
+	if best < 0 {
+		return 0, nil, fmt.Errorf("no element with index or name %q", strings.Join(tokens, "_"))
+	}
+
+	return best, tokens[bestLen:], nil
+}
+
+// setStructValue 按 mapstructure 标签解析配置项路径并设置值
+//
+// 初级工程师学习要点：
+// - 配置项名称本身可能包含下划线（如 max_open_conns），按标签逐个尝试，取最长匹配
+// - 值统一以字符串写入，解析到结构体时由 Viper 转换类型（数字、布尔值、时间间隔、逗号分隔的列表）
+func setStructValue(m map[string]any, t reflect.Type, tokens []string, value string) error {
+	if len(tokens) == 0 {
+		return fmt.Errorf("missing config key")
+	}
+
+	var match *reflect.StructField
+	var matchTag string
+	matchLen := 0
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("mapstructure")
+		tagTokens := strings.Split(tag, "_")
+		if tag != "" && tag != "-" && len(tagTokens) > matchLen && hasTokenPrefix(tokens, tagTokens) {
+			match, matchTag, matchLen = &field, tag, len(tagTokens)
+		}
+	}
+	if match == nil {
+		return fmt.Errorf("unknown config key %q", strings.Join(tokens, "_"))
+	}
+	rest := tokens[matchLen:]
+
+	switch {
+	case match.Type.Kind() == reflect.Struct:
+		child := toMap(m[matchTag])
+		if err := setStructValue(child, match.Type, rest, value); err != nil {
+			return err
+		}
+		m[matchTag] = child
+
+	case isStructSlice(match.Type):
+		if len(rest) == 0 {
+			return fmt.Errorf("missing index for %q", matchTag)
+		}
+		list, err := setListValue(toList(m[matchTag]), match.Type.Elem(), rest, value)
+		if err != nil {
+			return err
+		}
+		m[matchTag] = list
+
+	default:
+		if len(rest) > 0 {
+			return fmt.Errorf("unknown config key %q", strings.Join(tokens, "_"))
+		}
+		m[matchTag] = value
+	}
+
+	return nil
+}
+
+// hasTokenPrefix 判断 tokens 是否以 prefix 开头
+func hasTokenPrefix(tokens, prefix []string) bool {
+	if len(prefix) > len(tokens) {
+		return false
+	}
+	for i := range prefix {
+		if tokens[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// toList 将配置值转换为数组（不存在时返回空数组）
+func toList(value any) []any {
+	list, _ := value.([]any)
+	return append([]any(nil), list...)
+}
+
+// toMap 将配置值复制为 map（不存在时返回空 map），避免修改 Viper 内部的数据
+func toMap(value any) map[string]any {
+	m := make(map[string]any)
+	switch src := value.(type) {
+	case map[string]any:
+		for k, v := range src {
+			m[k] = v
+		}
+	case map[any]any:
+		for k, v := range src {
+			m[fmt.Sprint(k)] = v
+		}
+	}
+	return m
+}
+
+// stringToSliceHook 将逗号分隔的字符串转换为字符串数组（去掉空格和空元素）
+//
+// 初级工程师学习要点：
+// - 环境变量只能是字符串，列表配置用逗号分隔，如 GOFAST_MIDDLEWARE_CORS_ALLOW_ORIGINS=https://a.com, https://b.com
+func stringToSliceHook(from, to reflect.Type, data any) (any, error) {
+	if from.Kind() != reflect.String || to.Kind() != reflect.Slice {
+		return data, nil
+	}
+
+	result := []string{}
+	for _, item := range strings.Split(data.(string), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+// decodeHook 解析配置到结构体时使用的类型转换
+var decodeHook = viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+	mapstructure.StringToTimeDurationHookFunc(),
+	stringToSliceHook,
+))
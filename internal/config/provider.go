@@ -0,0 +1,353 @@
+// Package config 配置提供者（配置中心等额外的配置来源）
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// Provider 配置提供者
+//
+// 架构思路：
+// - 本地配置文件之外的配置来源（如配置中心）都实现这个接口
+// - Fetch 返回与配置文件结构相同的嵌套 map，按 config_providers 的顺序合并到本地配置文件之上
+// - Watch 在配置可能变化时调用 onChange，由 Watcher 重新加载全部配置（与配置文件变化的处理相同）
+//
+// 初级工程师学习要点：
+// - 内置 file（额外的配置文件）和 http（轮询 JSON 接口）两种类型
+// - Consul、etcd 等配置中心实现同样的接口，再用 RegisterProvider 注册类型即可使用
+// - Provider 在热更新之间复用（配置不变时），可以在内部缓存上一次的结果（如 HTTP 的 ETag）
+type Provider interface {
+	// Fetch 获取当前配置
+	Fetch(ctx context.Context) (map[string]any, error)
+
+	// Watch 监听配置变化，阻塞直到 ctx 取消
+	Watch(ctx context.Context, onChange func()) error
+}
+
+// ProviderConfig 配置提供者配置
+//
+// 初级工程师学习要点：
+// - 只能写在本地配置文件中，配置提供者返回的 config_providers 不会生效
+// - Optional 为 true 时，启动时获取失败不影响启动（配置中心短暂不可用时使用本地配置）
+// - 成功获取过之后再失败，热更新会被拒绝并继续使用旧配置，而不是悄悄退回本地配置
+type ProviderConfig struct {
+	Name         string        `mapstructure:"name"`                                  // 名称（用于配置来源，默认 类型[下标]）
+	Type         string        `mapstructure:"type" validate:"required"`              // 类型：file、http 或通过 RegisterProvider 注册的类型
+	Path         string        `mapstructure:"path" validate:"required_if=Type file"` // file：配置文件路径（yaml、json 等）
+	URL          string        `mapstructure:"url" validate:"required_if=Type http"`  // http：配置接口地址，返回 JSON
+	PollInterval time.Duration `mapstructure:"poll_interval" validate:"gte=0"`        // http：轮询间隔（默认 30s）
+	Timeout      time.Duration `mapstructure:"timeout" validate:"gte=0"`              // http：请求超时（默认 5s）
+	Optional     bool          `mapstructure:"optional"`                              // 获取失败时是否跳过
+}
+
+// ProviderFactory 根据配置创建配置提供者
+type ProviderFactory func(cfg ProviderConfig) (Provider, error)
+
+// 配置提供者的默认参数
+const (
+	defaultProviderPollInterval = 30 * time.Second
+	defaultProviderTimeout      = 5 * time.Second
+)
+
+var (
+	providerMu        sync.RWMutex
+	providerFactories = map[string]ProviderFactory{
+		"file": newFileProvider,
+		"http": newHTTPProvider,
+	}
+)
+
+// RegisterProvider 注册配置提供者类型（需要在加载配置之前调用）
+//
+// 使用示例：
+//
+//	config.RegisterProvider("consul", func(cfg config.ProviderConfig) (config.Provider, error) {
+//		return newConsulProvider(cfg.URL, cfg.Path)
+//	})
+func RegisterProvider(typ string, factory ProviderFactory) {
+	providerMu.Lock()
+	defer providerMu.Unlock()
+
+	providerFactories[typ] = factory
+}
+
+// lookupProvider 查找配置提供者类型
+func lookupProvider(typ string) (ProviderFactory, bool) {
+	providerMu.RLock()
+	defer providerMu.RUnlock()
+
+	factory, ok := providerFactories[typ]
+	return factory, ok
+}
+
+// providerEntry 已创建的配置提供者
+type providerEntry struct {
+	config   ProviderConfig // 补全默认名称后的配置
+	provider Provider
+	fetched  bool // 是否成功获取过配置
+}
+
+// openProviders 创建配置提供者，配置没有变化的复用上一次加载时创建的实例
+func openProviders(configs []ProviderConfig, prev []*providerEntry) ([]*providerEntry, error) {
+	entries := make([]*providerEntry, 0, len(configs))
+	for i, cfg := range configs {
+		if cfg.Name == "" {
+			cfg.Name = fmt.Sprintf("%s[%d]", cfg.Type, i)
+		}
+
+		if entry := findProvider(prev, cfg); entry != nil {
+			entries = append(entries, entry)
+			continue
+		}
+
+		factory, ok := lookupProvider(cfg.Type)
+		if !ok {
+			return nil, fmt.Errorf("config_providers[%d].type: unknown provider type %q", i, cfg.Type)
+		}
+		provider, err := factory(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("config_providers[%d]: %w", i, err)
+		}
+		entries = append(entries, &providerEntry{config: cfg, provider: provider})
+	}
+	return entries, nil
+}
+
+// findProvider 查找配置相同的配置提供者
+func findProvider(entries []*providerEntry, cfg ProviderConfig) *providerEntry {
+	for _, entry := range entries {
+		if reflect.DeepEqual(entry.config, cfg) {
+			return entry
+		}
+	}
+	return nil
+}
+
+// fetchProviders 依次获取配置提供者的配置并合并到 v
+//
+// 返回值：每个配置提供者的配置（用于查看配置来源，获取失败跳过的为 nil）
+func fetchProviders(v *viper.Viper, entries []*providerEntry) ([]map[string]any, error) {
+	values := make([]map[string]any, len(entries))
+	for i, entry := range entries {
+		m, err := fetchProvider(entry)
+		if err != nil {
+			if entry.config.Optional && !entry.fetched {
+				continue
+			}
+			return nil, fmt.Errorf("config provider %s: %w", entry.config.Name, err)
+		}
+
+		// 配置提供者不能再声明配置提供者
+		delete(m, "config_providers")
+		if err := v.MergeConfigMap(m); err != nil {
+			return nil, fmt.Errorf("config provider %s: %w", entry.config.Name, err)
+		}
+		values[i] = m
+		entry.fetched = true
+	}
+	return values, nil
+}
+
+// fetchProvider 获取单个配置提供者的配置（带超时）
+func fetchProvider(entry *providerEntry) (map[string]any, error) {
+	timeout := entry.config.Timeout
+	if timeout <= 0 {
+		timeout = defaultProviderTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return entry.provider.Fetch(ctx)
+}
+
+// ==================== file 配置提供者 ====================
+
+// fileProvider 读取额外的配置文件（如挂载的公共配置）
+type fileProvider struct {
+	path string
+}
+
+// newFileProvider 创建 file 配置提供者
+func newFileProvider(cfg ProviderConfig) (Provider, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("path is required for file provider")
+	}
+	return &fileProvider{path: cfg.Path}, nil
+}
+
+// Fetch 读取配置文件（格式由扩展名决定）
+func (p *fileProvider) Fetch(ctx context.Context) (map[string]any, error) {
+	v := viper.New()
+	v.SetConfigFile(p.path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", p.path, err)
+	}
+	return v.AllSettings(), nil
+}
+
+// Watch 监听配置文件所在目录（规则与 Watcher 监听本地配置文件相同）
+func (p *fileProvider) Watch(ctx context.Context, onChange func()) error {
+	fs, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer fs.Close()
+
+	if err := fs.Add(filepath.Dir(p.path)); err != nil {
+		return fmt.Errorf("failed to watch %s: %w", p.path, err)
+	}
+
+	path := filepath.Clean(p.path)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-fs.Events:
+			if !ok {
+				return nil
+			}
+			name := filepath.Clean(event.Name)
+			if !event.Has(fsnotify.Chmod) && (name == path || filepath.Base(name) == "..data") {
+				onChange()
+			}
+		}
+	}
+}
+
+// ==================== http 配置提供者 ====================
+
+// httpProvider 轮询 HTTP 接口获取 JSON 配置
+//
+// 架构思路：
+// - 请求时带上 If-None-Match（上一次响应的 ETag），配置没有变化时服务端返回 304，不用重新传输
+// - 服务端不支持 ETag 时比较响应内容的哈希，内容相同不触发重新加载
+// - Watch 按 poll_interval 轮询，配置变化或开始请求失败时通知 Watcher
+//
+// 初级工程师学习要点：
+// - 配置中心通常只需要提供一个返回 JSON 的接口，本地测试用任意静态文件服务器即可
+// - 轮询失败时 Watcher 重新加载会失败并记录日志，继续使用旧配置，恢复后自动生效
+type httpProvider struct {
+	url      string
+	interval time.Duration
+	client   *http.Client
+
+	mu   sync.Mutex // 保证同一时间只有一个请求（Fetch 和 Watch 共用缓存）
+	etag string
+	body []byte
+	sum  [sha256.Size]byte
+}
+
+// newHTTPProvider 创建 http 配置提供者
+func newHTTPProvider(cfg ProviderConfig) (Provider, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("url is required for http provider")
+	}
+
+	interval := cfg.PollInterval
+	if interval <= 0 {
+		interval = defaultProviderPollInterval
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultProviderTimeout
+	}
+
+	return &httpProvider{
+		url:      cfg.URL,
+		interval: interval,
+		client:   &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Fetch 获取配置（未变化时使用缓存的内容）
+func (p *httpProvider) Fetch(ctx context.Context) (map[string]any, error) {
+	if _, err := p.poll(ctx); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	body := p.body
+	p.mu.Unlock()
+
+	// 每次重新解析，返回的 map 可以被调用方修改
+	var m map[string]any
+	if err := json.Unmarshal(body, &m); err != nil {
+		return nil, fmt.Errorf("invalid JSON from %s: %w", p.url, err)
+	}
+	return m, nil
+}
+
+// Watch 按轮询间隔检查配置是否变化
+func (p *httpProvider) Watch(ctx context.Context, onChange func()) error {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	failing := false
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		changed, err := p.poll(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		// 开始失败时也通知一次，让重新加载失败的日志记录下来
+		if changed || (err != nil && !failing) {
+			onChange()
+		}
+		failing = err != nil
+	}
+}
+
+// poll 请求配置接口，返回内容是否变化
+func (p *httpProvider) poll(ctx context.Context) (changed bool, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if p.etag != "" && p.body != nil {
+		req.Header.Set("If-None-Match", p.etag)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch %s: %w", p.url, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && p.body != nil:
+		return false, nil
+	case resp.StatusCode != http.StatusOK:
+		return false, fmt.Errorf("failed to fetch %s: unexpected status %s", p.url, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", p.url, err)
+	}
+
+	sum := sha256.Sum256(body)
+	changed = p.body == nil || sum != p.sum
+	p.etag, p.body, p.sum = resp.Header.Get("ETag"), body, sum
+	return changed, nil
+}
@@ -0,0 +1,241 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// configServer 模拟配置中心：返回 JSON 配置，支持 ETag，可以切换为返回错误
+type configServer struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	body        string
+	status      int // 非 0 时直接返回该状态码
+	requests    int
+	notModified int
+}
+
+// newConfigServer 创建配置中心测试服务
+func newConfigServer(t *testing.T, body string) *configServer {
+	t.Helper()
+
+	s := &configServer{body: body}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *configServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests++
+	if s.status != 0 {
+		w.WriteHeader(s.status)
+		return
+	}
+
+	etag := fmt.Sprintf(`"%x"`, sha256.Sum256([]byte(s.body)))
+	if r.Header.Get("If-None-Match") == etag {
+		s.notModified++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(w, s.body)
+}
+
+// set 修改返回的配置和状态码
+func (s *configServer) set(body string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.body, s.status = body, status
+}
+
+// stats 返回请求次数和返回 304 的次数
+func (s *configServer) stats() (requests, notModified int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests, s.notModified
+}
+
+// newTestHTTPProvider 创建指向测试服务的 http 配置提供者
+func newTestHTTPProvider(t *testing.T, url string, interval time.Duration) Provider {
+	t.Helper()
+
+	p, err := newHTTPProvider(ProviderConfig{Type: "http", URL: url, PollInterval: interval})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// useConfigFile 在临时目录写入 config.yaml 并切换工作目录（load 会在当前目录查找配置文件）
+func useConfigFile(t *testing.T, content string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+}
+
+func TestHTTPProviderReusesBodyOnNotModified(t *testing.T) {
+	server := newConfigServer(t, `{"app":{"name":"remote"}}`)
+	p := newTestHTTPProvider(t, server.URL, time.Hour)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		m, err := p.Fetch(ctx)
+		if err != nil {
+			t.Fatalf("Fetch #%d: %v", i+1, err)
+		}
+		app, _ := m["app"].(map[string]any)
+		if app["name"] != "remote" {
+			t.Fatalf("Fetch #%d: got %v", i+1, m)
+		}
+		// 返回的 map 每次重新解析，调用方修改不影响下一次的结果
+		app["name"] = "modified"
+	}
+
+	if requests, notModified := server.stats(); requests != 3 || notModified != 2 {
+		t.Errorf("got %d requests and %d 304 responses, want 3 and 2", requests, notModified)
+	}
+}
+
+func TestHTTPProviderWatch(t *testing.T) {
+	server := newConfigServer(t, `{"app":{"name":"v1"}}`)
+	p := newTestHTTPProvider(t, server.URL, 10*time.Millisecond)
+	if _, err := p.Fetch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	changes := make(chan struct{}, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- p.Watch(ctx, func() { changes <- struct{}{} }) }()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Watch: %v", err)
+		}
+	}()
+
+	// 内容没有变化（304）时不通知
+	select {
+	case <-changes:
+		t.Fatal("onChange called without a change")
+	case <-time.After(100 * time.Millisecond):
+	}
+	if _, notModified := server.stats(); notModified == 0 {
+		t.Fatal("expected polls to be answered with 304")
+	}
+
+	// 内容变化时通知
+	server.set(`{"app":{"name":"v2"}}`, 0)
+	select {
+	case <-changes:
+	case <-time.After(2 * time.Second):
+		t.Fatal("onChange not called after the body changed")
+	}
+
+	m, err := p.Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if app, _ := m["app"].(map[string]any); app["name"] != "v2" {
+		t.Errorf("got %v after change, want v2", m)
+	}
+}
+
+func TestLoadProviderFailureAtStartup(t *testing.T) {
+	server := newConfigServer(t, "")
+	server.set("", http.StatusServiceUnavailable)
+
+	tests := []struct {
+		name     string
+		optional bool
+		wantErr  bool
+	}{
+		{name: "optional provider is skipped", optional: true},
+		{name: "required provider fails", optional: false, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useConfigFile(t, fmt.Sprintf(`
+app:
+  name: local
+config_providers:
+  - type: http
+    url: %s
+    optional: %t
+`, server.URL, tt.optional))
+
+			w, err := NewWatcher()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error when a required provider fails")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewWatcher: %v", err)
+			}
+			if got := w.Config().App.Name; got != "local" {
+				t.Errorf("app.name = %q, want local", got)
+			}
+		})
+	}
+}
+
+func TestReloadKeepsConfigWhenProviderFailsAfterFetch(t *testing.T) {
+	server := newConfigServer(t, `{"app":{"name":"remote"}}`)
+	// optional 只影响启动：成功获取过之后再失败，热更新也要被拒绝
+	useConfigFile(t, fmt.Sprintf(`
+app:
+  name: local
+config_providers:
+  - type: http
+    url: %s
+    optional: true
+`, server.URL))
+
+	w, err := NewWatcher()
+	if err != nil {
+		t.Fatalf("NewWatcher: %v", err)
+	}
+	if got := w.Config().App.Name; got != "remote" {
+		t.Fatalf("app.name = %q, want remote", got)
+	}
+
+	server.set("", http.StatusInternalServerError)
+	changed, err := w.Reload()
+	if err == nil || changed {
+		t.Fatalf("Reload: changed %t, err %v; want an error", changed, err)
+	}
+	if got := w.Config().App.Name; got != "remote" {
+		t.Errorf("app.name = %q after failed reload, want remote", got)
+	}
+
+	// 配置中心恢复后重新加载成功
+	server.set(`{"app":{"name":"recovered"}}`, 0)
+	if _, err := w.Reload(); err != nil {
+		t.Fatalf("Reload after recovery: %v", err)
+	}
+	if got := w.Config().App.Name; got != "recovered" {
+		t.Errorf("app.name = %q after recovery, want recovered", got)
+	}
+}
@@ -0,0 +1,216 @@
+// Package config 配置的 JSON Schema 与未知配置项检查
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// durationPattern 时间间隔的格式（与 time.ParseDuration 一致，如 30s、1m30s、500ms）
+const durationPattern = `^-?(0|([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+)$`
+
+// JSONSchema 根据 Config 结构体生成配置文件的 JSON Schema
+//
+// 架构思路：
+// - 配置项名称来自 mapstructure tag，与配置文件一致
+// - 枚举值（oneof）、取值范围（gte、lte 等）、前缀（startswith）来自 validate tag，与验证规则保持同步
+// - 所有对象都设置 additionalProperties: false，拼写错误的配置项会被编辑器和 CI 标记出来
+//
+// 初级工程师学习要点：
+// - 生成命令：go run ./cmd/config schema > config/config.schema.json
+// - 配置文件第一行的 # yaml-language-server: $schema=... 让编辑器（VS Code YAML 插件等）自动补全和检查
+// - 修改 Config 结构体后重新生成，否则编辑器会把新配置项标记为错误
+// - 必填规则大多依赖模块是否启用，Schema 中不声明 required，完整验证仍以 validate 为准
+func JSONSchema() map[string]any {
+	schema := typeSchema(reflect.TypeOf(Config{}), "")
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "GoFast config"
+	return schema
+}
+
+// typeSchema 生成单个类型的 Schema，rules 为字段的 validate tag
+func typeSchema(t reflect.Type, rules string) map[string]any {
+	fieldRules, itemRules, _ := strings.Cut(rules, "dive")
+
+	var schema map[string]any
+	switch {
+	case t == durationType:
+		schema = map[string]any{
+			"type":        "string",
+			"pattern":     durationPattern,
+			"description": "时间间隔，如 500ms、30s、1m30s",
+		}
+
+	case t.Kind() == reflect.Struct:
+		properties := make(map[string]any)
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag := field.Tag.Get("mapstructure")
+			if tag == "" || tag == "-" {
+				continue
+			}
+			properties[tag] = typeSchema(field.Type, field.Tag.Get("validate"))
+		}
+		schema = map[string]any{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+
+	case t.Kind() == reflect.Map:
+		schema = map[string]any{
+			"type":                 "object",
+			"additionalProperties": typeSchema(t.Elem(), ""),
+		}
+
+	case t.Kind() == reflect.Slice:
+		schema = map[string]any{
+			"type":  "array",
+			"items": typeSchema(t.Elem(), strings.TrimPrefix(itemRules, ",")),
+		}
+
+	case t.Kind() == reflect.String:
+		schema = map[string]any{"type": "string"}
+
+	case t.Kind() == reflect.Bool:
+		schema = map[string]any{"type": "boolean"}
+
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		schema = map[string]any{"type": "number"}
+
+	default:
+		schema = map[string]any{"type": "integer"}
+	}
+
+	applyRules(schema, strings.TrimSuffix(fieldRules, ","))
+	return schema
+}
+
+// applyRules 将 validate tag 中能用 Schema 表达的规则写入 Schema
+func applyRules(schema map[string]any, rules string) {
+	if rules == "" {
+		return
+	}
+
+	numeric := schema["type"] == "integer" || schema["type"] == "number"
+	omitempty := false
+	for _, rule := range strings.Split(rules, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "omitempty":
+			omitempty = true
+		case "oneof":
+			var values []any
+			if omitempty {
+				values = append(values, "")
+			}
+			for _, value := range strings.Fields(param) {
+				values = append(values, value)
+			}
+			schema["enum"] = values
+		case "startswith":
+			schema["pattern"] = "^" + regexp.QuoteMeta(param)
+		case "gte", "min", "gt", "lte", "max":
+			n, err := strconv.ParseFloat(param, 64)
+			if !numeric || err != nil {
+				continue
+			}
+			key := map[string]string{"gte": "minimum", "min": "minimum", "gt": "exclusiveMinimum", "lte": "maximum", "max": "maximum"}[name]
+			schema[key] = n
+		}
+	}
+}
+
+// unknownKeys 检查配置中不存在于 Config 结构体的配置项（严格模式使用）
+//
+// 初级工程师学习要点：
+// - 拼写错误的配置项（如 max_idel_conns）在普通模式下会被忽略，对应字段悄悄使用零值
+// - 严格模式下每一层配置（配置文件、配置提供者）分别检查，错误中带上完整路径和所在文件
+// - 找到相近的配置项名称时给出提示（did you mean ...）
+func unknownKeys(layers []sourceLayer) ValidationErrors {
+	var errs ValidationErrors
+	for _, layer := range layers {
+		walkUnknown(reflect.TypeOf(Config{}), layer.values.AllSettings(), "", func(path, hint string) {
+			message := "is not a valid config key (" + layer.source.String() + ")"
+			if hint != "" {
+				message += ", did you mean " + hint + "?"
+			}
+			errs = append(errs, FieldError{Path: path, Message: message})
+		})
+	}
+	return errs
+}
+
+// walkUnknown 按结构体的 mapstructure tag 检查 map 中的每个配置项
+func walkUnknown(t reflect.Type, value any, path string, report func(path, hint string)) {
+	m, ok := value.(map[string]any)
+	if !ok || t.Kind() != reflect.Struct {
+		return
+	}
+
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("mapstructure")
+		if tag != "" && tag != "-" {
+			fields[tag] = t.Field(i).Type
+		}
+	}
+
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		keyPath := joinPath(path, key)
+		fieldType, ok := fields[key]
+		switch {
+		case !ok:
+			report(keyPath, closestKey(key, fields))
+		case fieldType.Kind() == reflect.Struct:
+			walkUnknown(fieldType, m[key], keyPath, report)
+		case isStructSlice(fieldType):
+			list, _ := m[key].([]any)
+			for i, item := range list {
+				walkUnknown(fieldType.Elem(), toMap(item), fmt.Sprintf("%s[%d]", keyPath, i), report)
+			}
+		}
+	}
+}
+
+// closestKey 查找编辑距离最小的配置项名称（差异太大时返回空）
+func closestKey(key string, fields map[string]reflect.Type) string {
+	best, bestDist := "", len(key)/2+1
+	for name := range fields {
+		if d := editDistance(key, name); d < bestDist || (d == bestDist && name < best) {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+// editDistance 计算两个字符串的编辑距离（插入、删除、替换各算一次）
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
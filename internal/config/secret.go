@@ -0,0 +1,352 @@
+// Package config 密钥引用与脱敏
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 加密配置值使用的本地密钥（二选一，内容为 base64 编码的 16/24/32 字节 AES 密钥）
+const (
+	SecretKeyEnv     = envPrefix + "_CONFIG_KEY"      // 直接提供密钥
+	SecretKeyFileEnv = envPrefix + "_CONFIG_KEY_FILE" // 密钥文件路径
+)
+
+// encryptedPrefix 加密配置值的前缀（enc:<base64(nonce + 密文)>）
+const encryptedPrefix = "enc:"
+
+// secretMask 脱敏后显示的值
+const secretMask = "******"
+
+// secretRef 匹配密钥引用，如 ${file:/run/secrets/db_pw}、${env:DB_PW}
+var secretRef = regexp.MustCompile(`\$\{([a-z]+):([^}]*)\}`)
+
+// secretResolver 解析配置中的密钥引用
+type secretResolver struct {
+	key     []byte          // 本地密钥（第一次遇到加密值时读取）
+	secrets map[string]bool // 值来自密钥引用的配置项
+	files   []string        // 读取过的密钥文件（热更新时监听）
+}
+
+// resolveSecrets 解析所有字符串配置项中的密钥引用
+//
+// 架构思路：
+// - ${file:路径}：读取文件内容（去掉末尾换行），适合 Kubernetes/Docker Secret 挂载的文件
+// - ${env:变量名}：读取环境变量，变量名不需要 GOFAST_ 前缀，适合复用已有的环境变量
+// - enc:密文：使用本地密钥（GOFAST_CONFIG_KEY 或 GOFAST_CONFIG_KEY_FILE）解密，加密值可以提交到仓库
+// - 在解析到结构体之后、验证之前执行，配置文件、环境变量、命令行参数中的引用都会被解析
+//
+// 初级工程师学习要点：
+// - ${...} 引用可以出现在字符串中间，如 redis://:${env:REDIS_PW}@127.0.0.1:6379
+// - enc: 必须是整个值的前缀
+// - 引用的文件或环境变量不存在、解密失败时返回错误，不会用空值启动
+// - 值来自引用的配置项会被记录下来，打印配置时脱敏（见 Config.Redacted）
+func resolveSecrets(cfg *Config) (files []string, err error) {
+	r := &secretResolver{secrets: make(map[string]bool)}
+	if err := r.walk(reflect.ValueOf(cfg).Elem(), ""); err != nil {
+		return nil, err
+	}
+
+	cfg.secrets = r.secrets
+	return r.files, nil
+}
+
+// walk 遍历结构体、数组和 map 中的字符串
+func (r *secretResolver) walk(v reflect.Value, path string) error {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			tag := t.Field(i).Tag.Get("mapstructure")
+			if tag == "" || tag == "-" {
+				continue
+			}
+			if err := r.walk(v.Field(i), joinPath(path, tag)); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := r.walk(v.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		if v.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			key := fmt.Sprintf("%s.%v", path, iter.Key())
+			value, resolved, err := r.resolve(iter.Value().String())
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			if resolved {
+				v.SetMapIndex(iter.Key(), reflect.ValueOf(value).Convert(v.Type().Elem()))
+				r.secrets[key] = true
+			}
+		}
+
+	case reflect.String:
+		value, resolved, err := r.resolve(v.String())
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if resolved {
+			v.SetString(value)
+			r.secrets[path] = true
+		}
+	}
+
+	return nil
+}
+
+// resolve 解析单个值，resolved 表示值中包含密钥引用
+func (r *secretResolver) resolve(value string) (result string, resolved bool, err error) {
+	if strings.HasPrefix(value, encryptedPrefix) {
+		if r.key == nil {
+			if r.key, err = loadSecretKey(); err != nil {
+				return "", false, err
+			}
+		}
+		plaintext, err := DecryptSecret(r.key, value)
+		if err != nil {
+			return "", false, err
+		}
+		return plaintext, true, nil
+	}
+
+	if !strings.Contains(value, "${") {
+		return value, false, nil
+	}
+
+	result = secretRef.ReplaceAllStringFunc(value, func(ref string) string {
+		if err != nil {
+			return ""
+		}
+		m := secretRef.FindStringSubmatch(ref)
+		var s string
+		s, err = r.lookup(m[1], m[2])
+		return s
+	})
+	if err != nil {
+		return "", false, err
+	}
+
+	return result, result != value, nil
+}
+
+// lookup 读取 ${kind:name} 引用的值
+func (r *secretResolver) lookup(kind, name string) (string, error) {
+	switch kind {
+	case "file":
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret file: %w", err)
+		}
+		r.files = append(r.files, name)
+		return strings.TrimRight(string(data), "\r\n"), nil
+
+	case "env":
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return value, nil
+
+	default:
+		return "", fmt.Errorf("unknown secret reference ${%s:%s}, must be file or env", kind, name)
+	}
+}
+
+// loadSecretKey 读取解密配置值使用的本地密钥
+func loadSecretKey() ([]byte, error) {
+	encoded := os.Getenv(SecretKeyEnv)
+	if path := os.Getenv(SecretKeyFileEnv); encoded == "" && path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config key file: %w", err)
+		}
+		encoded = string(data)
+	}
+	if encoded == "" {
+		return nil, fmt.Errorf("encrypted value requires %s or %s", SecretKeyEnv, SecretKeyFileEnv)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("invalid config key: %w", err)
+	}
+	return key, nil
+}
+
+// GenerateSecretKey 生成新的本地密钥（base64 编码的 32 字节 AES-256 密钥）
+func GenerateSecretKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// EncryptSecret 使用本地密钥加密配置值，返回 enc: 开头的值，可以直接写入配置文件
+//
+// 初级工程师学习要点：
+// - 使用 AES-GCM 加密，同时保证机密性和完整性（密文被篡改时解密失败）
+// - 每次加密使用随机 nonce，同一个明文每次加密的结果都不同
+func EncryptSecret(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSecret 解密 EncryptSecret 生成的配置值
+func DecryptSecret(key []byte, value string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted value: %w", err)
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("invalid encrypted value: too short")
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value (wrong key or corrupted value)")
+	}
+	return string(plaintext), nil
+}
+
+// newGCM 使用密钥创建 AES-GCM
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// SecretKeys 返回值来自密钥引用的配置项（排序后，如 databases[0].master.password）
+func (c *Config) SecretKeys() []string {
+	keys := make([]string, 0, len(c.secrets))
+	for key := range c.secrets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Redacted 返回脱敏后的配置（键为配置文件中的名称），用于打印或导出配置
+//
+// 架构思路：
+// - 值来自密钥引用的配置项，以及标记了 secret:"true" 的配置项（密码、JWT 密钥）显示为 ******
+// - 未设置的密钥保持为空，方便发现漏配
+// - 时间间隔显示为 30s 这样的格式，与配置文件的写法一致
+//
+// 初级工程师学习要点：
+// - Config 实现了 String 和 MarshalJSON，直接打印或作为日志字段输出时也会脱敏
+// - 单独打印某个子配置（如 cfg.Redis）不会脱敏，打印配置时统一使用 Redacted
+func (c *Config) Redacted() map[string]any {
+	m, _ := redact(reflect.ValueOf(*c), "", false, c.secrets).(map[string]any)
+	return m
+}
+
+// String 返回脱敏后的 JSON
+func (c Config) String() string {
+	data, err := c.MarshalJSON()
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
+
+// MarshalJSON 输出脱敏后的配置
+func (c Config) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.Redacted())
+}
+
+// durationType time.Duration 的类型（按字符串格式输出）
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// redact 将配置值转换为脱敏后的 map/数组/基本类型
+func redact(v reflect.Value, path string, secret bool, secrets map[string]bool) any {
+	switch {
+	case v.Type() == durationType:
+		return time.Duration(v.Int()).String()
+
+	case v.Kind() == reflect.Struct:
+		m := make(map[string]any)
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag := field.Tag.Get("mapstructure")
+			if tag == "" || tag == "-" {
+				continue
+			}
+			key := joinPath(path, tag)
+			m[tag] = redact(v.Field(i), key, field.Tag.Get("secret") == "true", secrets)
+		}
+		return m
+
+	case v.Kind() == reflect.Map:
+		m := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key())
+			m[key] = redact(iter.Value(), joinPath(path, key), secret, secrets)
+		}
+		return m
+
+	case v.Kind() == reflect.Slice:
+		list := make([]any, v.Len())
+		for i := range list {
+			list[i] = redact(v.Index(i), fmt.Sprintf("%s[%d]", path, i), secret, secrets)
+		}
+		return list
+
+	case v.Kind() == reflect.String:
+		if v.String() != "" && (secret || secrets[path]) {
+			return secretMask
+		}
+		return v.String()
+
+	default:
+		return v.Interface()
+	}
+}
+
+// joinPath 拼接配置项路径
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
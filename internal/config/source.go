@@ -0,0 +1,194 @@
+// Package config 配置文件分层与配置来源
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// 环境变量前缀和键名转换（server.http.port -> GOFAST_SERVER_HTTP_PORT）
+const envPrefix = "GOFAST"
+
+var envKeyReplacer = strings.NewReplacer(".", "_")
+
+// 配置项来源类型
+const (
+	SourceDefault  = "default"  // 默认值
+	SourceFile     = "file"     // 配置文件
+	SourceProvider = "provider" // 配置提供者（如配置中心）
+	SourceEnv      = "env"      // 环境变量
+	SourceFlag     = "flag"     // 命令行参数
+)
+
+// Source 配置项的实际来源
+type Source struct {
+	Kind string // 来源类型：default、file、provider、env、flag
+	Name string // 文件路径、配置提供者名称、环境变量名或参数名（默认值为空）
+}
+
+// String 返回来源描述，如 file:config/config.dev.yaml、env:GOFAST_SERVER_HTTP_PORT
+func (s Source) String() string {
+	if s.Name == "" {
+		return s.Kind
+	}
+	return s.Kind + ":" + s.Name
+}
+
+// Sources 配置项 -> 来源（键为 server.http.port 形式）
+//
+// 初级工程师学习要点：
+// - 配置来自多个地方时，排查"为什么这个值不对"最快的办法是看它从哪里来
+// - 数组（如 databases）整体作为一个配置项，覆盖文件中的数组会整体替换而不是合并
+type Sources map[string]Source
+
+// Keys 返回排序后的配置项列表
+func (s Sources) Keys() []string {
+	keys := make([]string, 0, len(s))
+	for key := range s {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// readConfigFiles 读取基础配置文件，并依次合并环境覆盖文件和本地覆盖文件
+//
+// 架构思路：
+// - 基础文件：--config 指定的文件；未指定时在 .、./config、/etc/gofast/ 中查找 config.yaml
+// - 环境覆盖文件：与基础文件同目录的 config.<env>.yaml（env 为最终生效的 app.env）
+// - 本地覆盖文件：与基础文件同目录的 config.local.yaml（个人开发配置，不提交到仓库）
+// - 后合并的文件覆盖先合并的文件，覆盖文件不存在时跳过
+//
+// 初级工程师学习要点：
+// - --config 指定的文件不存在是错误；自动查找时找不到配置文件不是错误，使用默认值即可
+// - 返回的 watch 包含还不存在的覆盖文件，新建覆盖文件也能触发热更新
+func readConfigFiles(v *viper.Viper) (files, watch []string, err error) {
+	base := configFlag()
+	if base != "" {
+		v.SetConfigFile(base)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, nil, fmt.Errorf("failed to read config file %s: %w", base, err)
+		}
+	} else {
+		v.SetConfigName("config")       // 配置文件名（不含扩展名）
+		v.SetConfigType("yaml")         // 配置文件类型
+		v.AddConfigPath(".")            // 当前目录
+		v.AddConfigPath("./config")     // config 目录
+		v.AddConfigPath("/etc/gofast/") // 系统配置目录
+
+		if err := v.ReadInConfig(); err != nil {
+			if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+				return nil, nil, fmt.Errorf("failed to read config file: %w", err)
+			}
+			return nil, nil, nil
+		}
+		base = v.ConfigFileUsed()
+	}
+	files = append(files, base)
+	watch = append(watch, base)
+
+	dir, ext := filepath.Dir(base), filepath.Ext(base)
+	name := strings.TrimSuffix(filepath.Base(base), ext)
+	overlays := []string{
+		filepath.Join(dir, name+"."+v.GetString("app.env")+ext),
+		filepath.Join(dir, name+".local"+ext),
+	}
+	for _, path := range overlays {
+		watch = append(watch, path)
+
+		if _, err := os.Stat(path); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+		}
+
+		v.SetConfigFile(path)
+		if err := v.MergeInConfig(); err != nil {
+			return nil, nil, fmt.Errorf("failed to merge config file %s: %w", path, err)
+		}
+		files = append(files, path)
+	}
+
+	return files, watch, nil
+}
+
+// sourceLayer 一层配置（配置文件或配置提供者），用于判断配置项来自哪一层
+type sourceLayer struct {
+	source Source
+	values *viper.Viper
+}
+
+// fileLayers 单独读取每个配置文件（读取失败的跳过）
+func fileLayers(files []string) []sourceLayer {
+	var layers []sourceLayer
+	for _, path := range files {
+		values := viper.New()
+		values.SetConfigFile(path)
+		if err := values.ReadInConfig(); err == nil {
+			layers = append(layers, sourceLayer{source: Source{Kind: SourceFile, Name: path}, values: values})
+		}
+	}
+	return layers
+}
+
+// providerLayers 配置提供者返回的配置（获取失败跳过的没有对应的层）
+func providerLayers(providers []*providerEntry, values []map[string]any) []sourceLayer {
+	var layers []sourceLayer
+	for i, entry := range providers {
+		if values[i] == nil {
+			continue
+		}
+		layer := viper.New()
+		layer.MergeConfigMap(values[i])
+		layers = append(layers, sourceLayer{source: Source{Kind: SourceProvider, Name: entry.config.Name}, values: layer})
+	}
+	return layers
+}
+
+// collectSources 计算每个配置项的实际来源
+//
+// 初级工程师学习要点：
+// - 按优先级从高到低判断：命令行参数 > 环境变量 > 配置提供者、配置文件（后合并的优先）> 默认值
+// - 每个配置文件、配置提供者单独保存一份（layers），才能知道某个配置项出现在哪一层
+// - 数组（如 databases）中的配置项被环境变量覆盖时，整个数组的来源记为这些环境变量
+func collectSources(v *viper.Viper, layers []sourceLayer, listEnvs map[string][]string) Sources {
+	sources := make(Sources)
+	for _, key := range v.AllKeys() {
+		if names, ok := listEnvs[key]; ok {
+			sources[key] = Source{Kind: SourceEnv, Name: strings.Join(names, ",")}
+			continue
+		}
+		sources[key] = sourceOf(key, layers)
+	}
+
+	return sources
+}
+
+// sourceOf 判断单个配置项的来源
+func sourceOf(key string, layers []sourceLayer) Source {
+	if name, ok := flagKeys[key]; ok {
+		if f := pflag.Lookup(name); f != nil && f.Changed {
+			return Source{Kind: SourceFlag, Name: "--" + name}
+		}
+	}
+
+	env := envPrefix + "_" + strings.ToUpper(envKeyReplacer.Replace(key))
+	if os.Getenv(env) != "" {
+		return Source{Kind: SourceEnv, Name: env}
+	}
+
+	for i := len(layers) - 1; i >= 0; i-- {
+		if layers[i].values.IsSet(key) {
+			return layers[i].source
+		}
+	}
+
+	return Source{Kind: SourceDefault}
+}
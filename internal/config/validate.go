@@ -0,0 +1,338 @@
+// Package config 配置验证
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError 单个配置项的验证错误
+type FieldError struct {
+	Path    string // 配置项路径，如 databases[0].master.host
+	Message string // 错误说明，如 is required
+}
+
+// Error 返回 "路径 错误说明"
+func (e FieldError) Error() string {
+	return e.Path + " " + e.Message
+}
+
+// ValidationErrors 配置验证发现的所有错误
+//
+// 初级工程师学习要点：
+// - 一次列出所有问题，不用改一个错误重启一次
+// - 需要逐条处理时用 errors.As 取出（如 CI 中逐行输出）
+type ValidationErrors []FieldError
+
+// Error 返回所有错误（用分号分隔，方便在一行日志中查看）
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fe := range e {
+		messages[i] = fe.Error()
+	}
+	if len(messages) == 1 {
+		return messages[0]
+	}
+	return fmt.Sprintf("%d errors: %s", len(messages), strings.Join(messages, "; "))
+}
+
+// 生产环境的 JWT 密钥要求
+const (
+	minProdJWTSecretLength = 32                                     // 最短长度（HS256 建议至少 256 位）
+	defaultJWTSecret       = "your-secret-key-change-in-production" // 示例配置中的密钥
+)
+
+// identifierPattern 表名等 SQL 标识符（直接拼接在 SQL 中，只允许字母、数字和下划线）
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// fieldValidator 按 validate tag 验证结构体（键名使用 mapstructure tag，与配置文件一致）
+var fieldValidator = newFieldValidator()
+
+// newFieldValidator 创建结构体验证器
+func newFieldValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		return field.Tag.Get("mapstructure")
+	})
+	return v
+}
+
+// validation 收集验证错误
+type validation struct {
+	errs ValidationErrors
+}
+
+// add 记录一个错误
+func (v *validation) add(path, format string, args ...any) {
+	v.errs = append(v.errs, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// check 按 validate tag 验证结构体，path 为结构体在配置中的路径
+func (v *validation) check(path string, s any) {
+	err := fieldValidator.Struct(s)
+	if err == nil {
+		return
+	}
+
+	fieldErrs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		v.add(path, "%v", err)
+		return
+	}
+	for _, fe := range fieldErrs {
+		// Namespace 以结构体类型名开头，如 RedisConfig.health_check.interval
+		_, field, _ := strings.Cut(fe.Namespace(), ".")
+		fieldPath := joinPath(path, field)
+		parent := fieldPath[:max(strings.LastIndex(fieldPath, "."), 0)]
+		v.add(fieldPath, "%s", fieldMessage(fe, parent))
+	}
+}
+
+// fieldMessage 将 validate tag 的验证失败转换为错误说明，parent 用于拼接同级配置项的路径
+func fieldMessage(fe validator.FieldError, parent string) string {
+	param := fe.Param()
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "required_if":
+		field, value, _ := strings.Cut(param, " ")
+		return fmt.Sprintf("is required when %s is %s", joinPath(parent, snakeCase(field)), value)
+	case "required_with":
+		return fmt.Sprintf("is required when %s is set", joinPath(parent, snakeCase(param)))
+	case "oneof":
+		return "must be one of: " + strings.Join(strings.Fields(param), ", ")
+	case "gte":
+		if param == "0" {
+			return "must not be negative"
+		}
+		return "must be at least " + param
+	case "min":
+		return "must be at least " + param
+	case "gt":
+		return "must be greater than " + param
+	case "lte", "max":
+		return "must be at most " + param
+	case "gtfield":
+		return "must be greater than " + joinPath(parent, snakeCase(param))
+	case "startswith":
+		return "must start with " + param
+	case "hostname_port":
+		return fmt.Sprintf("must be in host:port format (got %q)", fe.Value())
+	default:
+		return fmt.Sprintf("failed on the %q rule", fe.Tag())
+	}
+}
+
+// snakeCase 将字段名转换为配置项名称（MasterName -> master_name）
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// validate 验证配置
+//
+// 架构思路：
+// - 在应用启动时验证配置，快速失败（Fail Fast）
+// - 单个字段的规则写在结构体的 validate tag 上（必填、枚举值、取值范围），一眼就能看到
+// - 字段之间的规则（如按数据库类型检查 host）和环境相关的规则（如生产环境禁止 * 跨域）写在代码中
+// - 所有错误收集起来一起返回（ValidationErrors），而不是遇到第一个错误就停止
+//
+// 初级工程师学习要点：
+// - 未启用的模块（如 redis.mode 为空、rate_limit.enabled 为 false）不验证，避免为不用的功能填配置
+// - 时间间隔、数量的零值通常表示使用默认值，所以大多只拒绝负数
+// - 错误中的路径与配置文件一致（如 databases[0].master.host），可以直接定位到出错的位置
+func validate(cfg *Config) error {
+	v := &validation{}
+
+	v.check("app", cfg.App)
+	v.check("server", cfg.Server)
+	v.check("logger", cfg.Logger)
+	v.check("health", cfg.Health)
+
+	v.validateDatabases(cfg.Databases)
+
+	for i, provider := range cfg.ConfigProviders {
+		v.check(fmt.Sprintf("config_providers[%d]", i), provider)
+	}
+
+	if cfg.Redis.Mode != "" {
+		v.check("redis", cfg.Redis)
+		v.validateRedisMode(cfg.Redis)
+	}
+
+	// 未配置 secret 表示不启用 JWT 认证
+	if cfg.JWT.Secret != "" {
+		v.check("jwt", cfg.JWT)
+	}
+
+	if cfg.Middleware.CORS.Enabled {
+		v.check("middleware.cors", cfg.Middleware.CORS)
+		if cfg.Middleware.CORS.AllowCredentials && containsWildcard(cfg.Middleware.CORS.AllowOrigins) {
+			v.add("middleware.cors.allow_origins", "cannot contain '*' when allow_credentials is true")
+		}
+	}
+
+	if cfg.Middleware.RateLimit.Enabled {
+		v.check("middleware.rate_limit", cfg.Middleware.RateLimit)
+	}
+
+	if cfg.Middleware.Trace.Enabled {
+		v.check("middleware.trace", cfg.Middleware.Trace)
+	}
+
+	if cfg.Metrics.Enabled {
+		v.check("metrics", cfg.Metrics)
+		// 独立端口不能与主 HTTP 端口相同，否则启动时会端口冲突
+		if cfg.Metrics.Port == cfg.Server.HTTP.Port {
+			v.add("metrics.port", "must differ from server.http.port (use 0 to share the HTTP server)")
+		}
+		// 挂载在主服务上时指标会随业务接口一起公开，只允许在开发环境这样做
+		if cfg.Metrics.Port == 0 && cfg.App.Env != "dev" {
+			v.add("metrics.port", "must not be 0 outside dev (metrics would be exposed on the public HTTP port)")
+		}
+	}
+
+	if cfg.App.Env == "prod" {
+		v.validateProd(cfg)
+	}
+
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+// validateDatabases 验证数据库配置
+//
+// 初级工程师学习要点：
+// - name 不能重复（使用 map 记录已出现的名称）
+// - mysql、postgres 的主从库都需要 host、port、username、database；sqlite 只需要 database（文件路径）
+// - max_idle_conns 超过 max_open_conns 没有意义，database/sql 会悄悄把它降下来
+// - migrations.table 会拼接在 SQL 中，只允许合法的标识符
+func (v *validation) validateDatabases(databases []DatabaseConfig) {
+	names := make(map[string]bool)
+
+	for i, db := range databases {
+		path := fmt.Sprintf("databases[%d]", i)
+		v.check(path, db)
+
+		if db.Name != "" {
+			if names[db.Name] {
+				v.add(path+".name", "'%s' is duplicated", db.Name)
+			}
+			names[db.Name] = true
+		}
+
+		if db.MaxOpenConns > 0 && db.MaxIdleConns > db.MaxOpenConns {
+			v.add(path+".max_idle_conns", "must not be greater than max_open_conns (%d)", db.MaxOpenConns)
+		}
+
+		if table := db.Migrations.Table; table != "" && !identifierPattern.MatchString(table) {
+			v.add(path+".migrations.table", "must be a valid table name (letters, digits and underscores)")
+		}
+
+		v.validateDBInstance(path+".master", db.Type, db.Master)
+		for j, slave := range db.Slaves {
+			v.validateDBInstance(fmt.Sprintf("%s.slaves[%d]", path, j), db.Type, slave)
+		}
+	}
+}
+
+// validateDBInstance 按数据库类型验证连接信息（设置了 dsn 时由驱动解析，不检查连接字段）
+func (v *validation) validateDBInstance(path, dbType string, instance DBInstanceConfig) {
+	if instance.DSN != "" {
+		return
+	}
+
+	switch dbType {
+	case "mysql", "postgres":
+		if instance.Host == "" {
+			v.add(path+".host", "is required for %s", dbType)
+		}
+		if instance.Port == 0 {
+			v.add(path+".port", "is required for %s", dbType)
+		}
+		if instance.Username == "" {
+			v.add(path+".username", "is required for %s", dbType)
+		}
+		if instance.Database == "" {
+			v.add(path+".database", "is required for %s", dbType)
+		}
+	case "sqlite":
+		if instance.Database == "" {
+			v.add(path+".database", "is required for sqlite (database file path)")
+		}
+	}
+}
+
+// validateRedisMode 按 Redis 模式验证地址配置
+func (v *validation) validateRedisMode(redis RedisConfig) {
+	switch redis.Mode {
+	case "standalone":
+		if redis.Addr == "" {
+			v.add("redis.addr", "is required when redis.mode is standalone")
+		}
+	case "sentinel":
+		if redis.MasterName == "" {
+			v.add("redis.master_name", "is required when redis.mode is sentinel")
+		}
+		if len(redis.SentinelAddrs) == 0 {
+			v.add("redis.sentinel_addrs", "is required when redis.mode is sentinel")
+		}
+	case "cluster":
+		if len(redis.ClusterAddrs) == 0 {
+			v.add("redis.cluster_addrs", "is required when redis.mode is cluster")
+		}
+	}
+}
+
+// validateProd 生产环境的额外规则
+//
+// 初级工程师学习要点：
+// - 开发环境为了方便可以宽松，生产环境必须堵住"示例配置直接上线"这类问题
+// - 使用示例配置中的 JWT 密钥等于把密钥公开，任何人都能伪造令牌
+// - 允许任意来源跨域（*）会让任何网站都能调用接口
+// - 跳过数据库证书验证等于没有 TLS，中间人可以冒充数据库
+func (v *validation) validateProd(cfg *Config) {
+	if secret := cfg.JWT.Secret; secret != "" {
+		if secret == defaultJWTSecret {
+			v.add("jwt.secret", "must not use the example secret in prod")
+		} else if len(secret) < minProdJWTSecretLength {
+			v.add("jwt.secret", "must be at least %d characters in prod", minProdJWTSecretLength)
+		}
+	}
+
+	if cfg.Middleware.CORS.Enabled && containsWildcard(cfg.Middleware.CORS.AllowOrigins) {
+		v.add("middleware.cors.allow_origins", "must not contain '*' in prod")
+	}
+
+	for i, db := range cfg.Databases {
+		if db.TLS.Enabled && db.TLS.InsecureSkipVerify {
+			v.add(fmt.Sprintf("databases[%d].tls.insecure_skip_verify", i), "must not be true in prod")
+		}
+	}
+}
+
+// containsWildcard 检查允许的来源中是否包含 *
+func containsWildcard(origins []string) bool {
+	for _, origin := range origins {
+		if origin == "*" {
+			return true
+		}
+	}
+	return false
+}
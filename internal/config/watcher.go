@@ -0,0 +1,317 @@
+// Package config 配置热更新
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// 触发配置重新加载的来源
+const (
+	ReloadSourceFile     = "file"     // 配置文件变化
+	ReloadSourceProvider = "provider" // 配置提供者通知变化
+	ReloadSourceSIGHUP   = "SIGHUP"   // 收到 SIGHUP 信号
+)
+
+// ChangeFunc 配置变化的订阅函数
+//
+// 初级工程师学习要点：
+// - old 和 new 都是完整配置，订阅者自己比较关心的部分是否变化
+// - 返回错误只会被记录，不会回滚配置（其他订阅者可能已经应用了新配置）
+type ChangeFunc func(old, new *Config) error
+
+// subscriber 已注册的订阅者
+type subscriber struct {
+	name string
+	fn   ChangeFunc
+}
+
+// Watcher 配置监听器
+//
+// 架构思路：
+// - 配置文件变化（fsnotify）、配置提供者通知变化或收到 SIGHUP 信号时，重新读取全部配置来源并验证
+// - 验证失败时拒绝更新，继续使用旧配置
+// - 验证通过后替换当前配置，按注册顺序通知订阅者
+//
+// 初级工程师学习要点：
+// - 监听的是配置文件所在的目录，这样文件被删除重建、编辑器"另存为替换"也能发现
+// - Kubernetes ConfigMap 更新时通过替换 ..data 符号链接完成，同样会触发重新加载
+// - 编辑器保存一次可能产生多个事件，等事件停止 100ms 后再加载，避免读到写了一半的文件
+// - 同一时间只有一次重新加载，订阅者不会并发收到通知
+// - 并不是所有配置都能热更新（如监听端口），对应模块不订阅即可
+//
+// 使用示例：
+//
+//	watcher, err := config.NewWatcher()
+//	cfg := watcher.Config()
+//	watcher.Subscribe("logger", func(old, new *config.Config) error {
+//		log.SetLevel(new.Logger.Level)
+//		return nil
+//	})
+//	watcher.Start(func(source string, changed bool, err error) { ... })
+//	defer watcher.Stop()
+type Watcher struct {
+	current atomic.Pointer[snapshot]
+
+	mu          sync.Mutex // 保证同一时间只有一次重新加载
+	subscribers []subscriber
+
+	stopped atomic.Bool
+	fs      *fsnotify.Watcher
+	signals chan os.Signal
+	done    chan struct{}
+	wg      sync.WaitGroup
+
+	providerEvents  chan struct{}      // 配置提供者通知变化
+	providers       []*providerEntry   // 正在监听的配置提供者
+	cancelProviders context.CancelFunc // 停止监听配置提供者
+}
+
+// reloadDebounce 文件事件停止后等待多久再重新加载
+const reloadDebounce = 100 * time.Millisecond
+
+// NewWatcher 加载配置并创建配置监听器（此时还不会监听变化，需要调用 Start）
+func NewWatcher() (*Watcher, error) {
+	s, err := load(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{}
+	w.current.Store(s)
+	return w, nil
+}
+
+// Config 返回当前配置
+//
+// 初级工程师学习要点：
+// - 返回的配置不要修改，热更新后会返回新的实例
+func (w *Watcher) Config() *Config {
+	return w.current.Load().config
+}
+
+// Files 返回当前配置实际读取的配置文件（按合并顺序）
+func (w *Watcher) Files() []string {
+	return w.current.Load().files
+}
+
+// Sources 返回当前配置每个配置项的来源（用于排查配置问题）
+func (w *Watcher) Sources() Sources {
+	return w.current.Load().sources
+}
+
+// Subscribe 注册配置变化订阅者
+func (w *Watcher) Subscribe(name string, fn ChangeFunc) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.subscribers = append(w.subscribers, subscriber{name: name, fn: fn})
+}
+
+// Start 开始监听配置文件变化和 SIGHUP 信号
+//
+// 初级工程师学习要点：
+// - 每次重新加载后调用 onReload，用于记录日志（config 包不依赖 logger，避免循环依赖）
+// - changed 为 false 表示配置没有变化（如编辑器保存了相同内容）或加载失败
+// - 文件监听创建失败时返回错误，此时仍然可以通过 SIGHUP 触发重新加载
+// - 环境变量的变化需要重启进程才能生效
+func (w *Watcher) Start(onReload func(source string, changed bool, err error)) error {
+	reload := func(source string) {
+		changed, err := w.Reload()
+		if onReload != nil {
+			onReload(source, changed, err)
+		}
+	}
+
+	w.signals = make(chan os.Signal, 1)
+	w.done = make(chan struct{})
+	w.providerEvents = make(chan struct{}, 1)
+	signal.Notify(w.signals, syscall.SIGHUP)
+
+	w.mu.Lock()
+	w.watchProviders(w.current.Load().providers)
+	w.mu.Unlock()
+
+	var events chan fsnotify.Event
+	var watchErr error
+	if watch := w.current.Load().watch; len(watch) > 0 {
+		w.fs, watchErr = fsnotify.NewWatcher()
+		if watchErr == nil {
+			events = w.fs.Events
+			for _, dir := range watchDirs(watch) {
+				if err := w.fs.Add(dir); err != nil {
+					watchErr = fmt.Errorf("failed to watch config directory %s: %w", dir, err)
+				}
+			}
+		}
+	}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+
+		var debounce <-chan time.Time
+		source := ReloadSourceFile
+		for {
+			select {
+			case <-w.done:
+				return
+			case <-w.signals:
+				reload(ReloadSourceSIGHUP)
+			case event, ok := <-events:
+				if !ok {
+					events = nil
+					continue
+				}
+				if w.isConfigEvent(event) {
+					debounce, source = time.After(reloadDebounce), ReloadSourceFile
+				}
+			case <-w.providerEvents:
+				debounce, source = time.After(reloadDebounce), ReloadSourceProvider
+			case <-debounce:
+				debounce = nil
+				reload(source)
+			}
+		}
+	}()
+
+	return watchErr
+}
+
+// isConfigEvent 判断文件事件是否与配置文件有关
+func (w *Watcher) isConfigEvent(event fsnotify.Event) bool {
+	if event.Has(fsnotify.Chmod) {
+		return false
+	}
+
+	name := filepath.Clean(event.Name)
+	if filepath.Base(name) == "..data" {
+		return true
+	}
+	for _, path := range w.current.Load().watch {
+		if filepath.Clean(path) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// watchProviders 监听配置提供者的变化（配置提供者变化时停止旧的监听）
+//
+// 初级工程师学习要点：
+// - 配置提供者的 Watch 只负责通知，重新加载统一在 Start 的循环中进行，和配置文件变化一样去抖动
+// - 通知使用容量为 1 的 channel，重新加载期间的多次通知合并为一次
+// - 调用方需要持有 w.mu
+func (w *Watcher) watchProviders(providers []*providerEntry) {
+	if w.providerEvents == nil || w.stopped.Load() || slices.Equal(w.providers, providers) {
+		return
+	}
+
+	if w.cancelProviders != nil {
+		w.cancelProviders()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	w.providers, w.cancelProviders = providers, cancel
+
+	for _, entry := range providers {
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			// 监听失败时该配置提供者只能通过 SIGHUP 触发重新加载
+			entry.provider.Watch(ctx, func() {
+				select {
+				case w.providerEvents <- struct{}{}:
+				default:
+				}
+			})
+		}()
+	}
+}
+
+// watchDirs 返回需要监听的目录（去重）
+func watchDirs(files []string) []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, file := range files {
+		dir := filepath.Dir(file)
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// Stop 停止监听（用于优雅关闭流程）
+func (w *Watcher) Stop() error {
+	if !w.stopped.CompareAndSwap(false, true) || w.done == nil {
+		return nil
+	}
+
+	signal.Stop(w.signals)
+	close(w.done)
+	w.mu.Lock()
+	if w.cancelProviders != nil {
+		w.cancelProviders()
+	}
+	w.mu.Unlock()
+	w.wg.Wait()
+
+	if w.fs != nil {
+		return w.fs.Close()
+	}
+	return nil
+}
+
+// Reload 重新加载配置并通知订阅者
+//
+// 初级工程师学习要点：
+// - 返回的 changed 表示配置是否变化（变化了才会通知订阅者）
+// - 读取或验证失败时返回错误，当前配置保持不变
+// - 订阅者的错误合并后返回，此时新配置已经生效
+func (w *Watcher) Reload() (changed bool, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	prev := w.current.Load()
+	s, err := load(prev)
+	if err != nil {
+		return false, err
+	}
+
+	w.current.Store(s)
+	w.watchProviders(s.providers)
+
+	// 基础文件可能换了目录（如在优先级更高的搜索目录中新建了配置文件）
+	if w.fs != nil {
+		for _, dir := range watchDirs(s.watch) {
+			w.fs.Add(dir)
+		}
+	}
+
+	old := prev.config
+	if reflect.DeepEqual(old, s.config) {
+		return false, nil
+	}
+
+	var errs []error
+	for _, sub := range w.subscribers {
+		if err := sub.fn(old, s.config); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sub.name, err))
+		}
+	}
+
+	return true, errors.Join(errs...)
+}
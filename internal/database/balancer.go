@@ -0,0 +1,129 @@
+// Package database 从库负载均衡
+package database
+
+import (
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+// 从库负载均衡策略
+const (
+	LoadBalanceRoundRobin = "round_robin"  // 轮询（默认）
+	LoadBalanceWeighted   = "weighted"     // 按权重平滑轮询
+	LoadBalanceRandom     = "random"       // 随机
+	LoadBalanceLeastInUse = "least_in_use" // 使用中连接数最少（按权重折算）
+)
+
+// candidate 可参与读请求的从库
+type candidate struct {
+	replica *replica
+	db      *gorm.DB
+}
+
+// balancer 从库负载均衡器
+//
+// 初级工程师学习要点：
+// - 不同策略实现同一个接口，Database 只依赖接口，按配置选择实现（策略模式）
+// - candidates 只包含健康且延迟未超限的从库，且不会为空
+type balancer interface {
+	pick(candidates []candidate) candidate
+}
+
+// newBalancer 根据配置创建负载均衡器（未知策略使用轮询）
+func newBalancer(strategy string) balancer {
+	switch strategy {
+	case LoadBalanceWeighted:
+		return &weightedBalancer{}
+	case LoadBalanceRandom:
+		return randomBalancer{}
+	case LoadBalanceLeastInUse:
+		return &leastInUseBalancer{}
+	default:
+		return &roundRobinBalancer{}
+	}
+}
+
+// roundRobinBalancer 轮询
+//
+// 初级工程师学习要点：
+// - 使用 atomic.Uint32 计数，无锁且线程安全
+type roundRobinBalancer struct {
+	index atomic.Uint32
+}
+
+func (b *roundRobinBalancer) pick(candidates []candidate) candidate {
+	return candidates[b.index.Add(1)%uint32(len(candidates))]
+}
+
+// weightedBalancer 平滑加权轮询（Nginx 使用的算法）
+//
+// 初级工程师学习要点：
+// - 每次选择时，所有从库的 currentWeight 加上自己的 weight，选出最大的一个
+// - 被选中的从库 currentWeight 减去总权重
+// - 权重 3:1 时选择顺序为 A A B A，而不是 A A A B，流量分布更平滑
+type weightedBalancer struct {
+	mu sync.Mutex
+}
+
+func (b *weightedBalancer) pick(candidates []candidate) candidate {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	total := 0
+	best := -1
+	for i, c := range candidates {
+		c.replica.currentWeight += c.replica.weight
+		total += c.replica.weight
+		if best < 0 || c.replica.currentWeight > candidates[best].replica.currentWeight {
+			best = i
+		}
+	}
+
+	candidates[best].replica.currentWeight -= total
+	return candidates[best]
+}
+
+// randomBalancer 随机
+type randomBalancer struct{}
+
+func (randomBalancer) pick(candidates []candidate) candidate {
+	return candidates[rand.IntN(len(candidates))]
+}
+
+// leastInUseBalancer 使用中连接数最少
+//
+// 初级工程师学习要点：
+// - 读取 sql.DBStats.InUse，慢查询多的从库会自然分到更少的请求
+// - 按权重折算（InUse / weight），规格大的从库可以承担更多并发
+// - 连接数相同时从轮询位置开始比较，避免空闲时总是选中第一个从库
+type leastInUseBalancer struct {
+	index atomic.Uint32
+}
+
+func (b *leastInUseBalancer) pick(candidates []candidate) candidate {
+	n := len(candidates)
+	start := int(b.index.Add(1) % uint32(n))
+
+	best := candidates[start]
+	bestLoad := load(best)
+	for i := 1; i < n; i++ {
+		c := candidates[(start+i)%n]
+		if l := load(c); l < bestLoad {
+			best, bestLoad = c, l
+		}
+	}
+
+	return best
+}
+
+// load 返回按权重折算后的使用中连接数
+func load(c candidate) float64 {
+	sqlDB, err := c.db.DB()
+	if err != nil {
+		return 0
+	}
+	return float64(sqlDB.Stats().InUse) / float64(c.replica.weight)
+}
@@ -10,12 +10,11 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
-	"sync/atomic"
+	"sync"
+	"time"
 
-	"gorm.io/driver/mysql"
-	"gorm.io/driver/postgres"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
 	"github.com/jingpc/awesome-be/internal/config"
@@ -28,13 +27,17 @@ import (
 // 初级工程师学习要点：
 // - Database 封装了 GORM 的 DB 实例
 // - 支持读写分离：master 用于写操作，slaves 用于读操作
-// - 使用 atomic.Uint32 实现轮询负载均衡（线程安全）
+// - 从库负载均衡策略可配置：轮询、加权、随机、最少使用中连接
+// - 后台定时检查从库，不健康的从库自动剔除，恢复后重新加入
 type Database struct {
-	name       string
-	config     config.DatabaseConfig
-	master     *gorm.DB      // 主库（写操作）
-	slaves     []*gorm.DB    // 从库列表（读操作）
-	slaveIndex atomic.Uint32 // 从库轮询索引
+	name     string
+	config   config.DatabaseConfig
+	master   *gorm.DB   // 主库（写操作）
+	slaves   []*replica // 从库列表（读操作）
+	balancer balancer   // 从库负载均衡策略
+
+	cancel context.CancelFunc // 停止从库后台检查
+	wg     sync.WaitGroup
 }
 
 // New 创建数据库实例
@@ -42,134 +45,187 @@ type Database struct {
 // 初级工程师学习要点：
 // - 这是工厂函数，根据配置创建数据库连接
 // - 支持多种数据库类型（MySQL、PostgreSQL、SQLite）
-// - 自动注册到健康检查管理器
+// - 自动注册到健康检查管理器（主库和每个从库各一个检查器）
 // - 接收 logger 参数，将 GORM 日志集成到统一日志系统
 func New(cfg config.DatabaseConfig, log *logger.Logger, healthMgr *health.Manager) (*Database, error) {
 	// 1. 创建主库连接
-	master, err := connect(cfg, cfg.Master, log)
+	master, err := connect(context.Background(), cfg, cfg.Master, log)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to master database: %w", err)
 	}
 
 	// 2. 配置连接池
-	sqlDB, err := master.DB()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get sql.DB: %w", err)
+	if err := configurePool(master, cfg); err != nil {
+		closeDB(master)
+		return nil, err
 	}
 
-	// 设置连接池参数
-	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
-	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
-	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
-	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	// 记录写入时间，用于 Read-Your-Writes
+	if err := registerWriteTracking(master, cfg.Name); err != nil {
+		closeDB(master)
+		return nil, err
+	}
+
+	db := &Database{
+		name:     cfg.Name,
+		config:   cfg,
+		master:   master,
+		balancer: newBalancer(cfg.LoadBalance),
+	}
 
 	// 3. 创建从库连接（如果配置了）
-	var slaves []*gorm.DB
 	for i, slaveCfg := range cfg.Slaves {
-		slave, err := connect(cfg, slaveCfg, log)
+		r := &replica{
+			name:   fmt.Sprintf("slave-%d", i),
+			config: slaveCfg,
+			weight: max(slaveCfg.Weight, 1),
+		}
+
+		slave, err := db.connectReplica(context.Background(), slaveCfg, log)
 		if err != nil {
 			// 从库连接失败不是致命错误，记录日志并继续
-			// 后续会降级到主库
+			// 读请求暂时降级到主库，后台检查会负责重连
 			log.Warn(fmt.Sprintf("failed to connect to slave database %d: %v", i, err))
-			continue
+			r.err = err
+		} else {
+			r.db = slave
+			r.healthy = true
 		}
 
-		// 配置从库连接池
-		slaveSQLDB, _ := slave.DB()
-		slaveSQLDB.SetMaxIdleConns(cfg.MaxIdleConns)
-		slaveSQLDB.SetMaxOpenConns(cfg.MaxOpenConns)
-		slaveSQLDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
-		slaveSQLDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
-
-		slaves = append(slaves, slave)
-	}
-
-	db := &Database{
-		name:   cfg.Name,
-		config: cfg,
-		master: master,
-		slaves: slaves,
+		db.slaves = append(db.slaves, r)
 	}
 
 	// 4. 验证数据库连接（执行 SELECT 1）
 	ctx := context.Background()
 	if err := master.WithContext(ctx).Exec("SELECT 1").Error; err != nil {
+		// 后台检查还没有启动，Close 只会关闭主库和已连接的从库
+		db.Close()
 		return nil, fmt.Errorf("failed to verify database connection: %w", err)
 	}
 
 	// 5. 注册健康检查（如果提供了 healthMgr）
-	if healthMgr != nil {
-		checker := &DatabaseHealthChecker{
-			name: cfg.Name,
-			db:   master,
-		}
-		healthMgr.Register(checker)
+	db.registerHealthCheckers(healthMgr)
+
+	// 6. 启动从库后台检查
+	if len(db.slaves) > 0 {
+		monitorCtx, cancel := context.WithCancel(context.Background())
+		db.cancel = cancel
+		db.wg.Add(1)
+		go func() {
+			defer db.wg.Done()
+			db.monitorReplicas(monitorCtx, log)
+		}()
 	}
 
 	return db, nil
 }
 
+// registerHealthCheckers 为主库和每个从库注册健康检查器（healthMgr 为 nil 时跳过）
+func (d *Database) registerHealthCheckers(healthMgr *health.Manager) {
+	if healthMgr == nil {
+		return
+	}
+
+	checker := &DatabaseHealthChecker{
+		name: d.name,
+		db:   d.master,
+	}
+	healthMgr.Register(checker, d.config.HealthCheck)
+	d.registerReplicaCheckers(healthMgr)
+}
+
+// unregisterHealthCheckers 注销主库和所有从库的健康检查器
+func (d *Database) unregisterHealthCheckers(healthMgr *health.Manager) {
+	if healthMgr == nil {
+		return
+	}
+
+	healthMgr.Unregister(d.name)
+	for _, r := range d.slaves {
+		healthMgr.Unregister(d.name + "/" + r.name)
+	}
+}
+
+// configurePool 设置连接池参数
+func configurePool(db *gorm.DB, cfg config.DatabaseConfig) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get sql.DB: %w", err)
+	}
+
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	return nil
+}
+
 // connect 创建数据库连接
 //
 // 初级工程师学习要点：
-// - 根据数据库类型选择不同的驱动
-// - 构建 DSN（Data Source Name）连接字符串
+// - 根据数据库类型选择不同的驱动，连接参数的组装见 dialector
 // - 使用自定义 GORM 日志适配器集成到统一日志系统
-func connect(cfg config.DatabaseConfig, instance config.DBInstanceConfig, log *logger.Logger) (*gorm.DB, error) {
-	var dialector gorm.Dialector
-
-	switch cfg.Type {
-	case "mysql":
-		dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=%t&loc=%s",
-			instance.Username,
-			instance.Password,
-			instance.Host,
-			instance.Port,
-			instance.Database,
-			instance.Charset,
-			instance.ParseTime,
-			instance.Loc,
-		)
-		dialector = mysql.Open(dsn)
-
-	case "postgres":
-		dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-			instance.Host,
-			instance.Port,
-			instance.Username,
-			instance.Password,
-			instance.Database,
-			instance.SSLMode,
-		)
-		dialector = postgres.Open(dsn)
-
-	case "sqlite":
-		dialector = sqlite.Open(instance.Database)
-
-	default:
-		return nil, fmt.Errorf("unsupported database type: %s", cfg.Type)
+// - 注册链路追踪回调，SQL 执行情况会出现在请求的调用链中
+// - 关闭 GORM 自带的 Ping，改用带 ctx 的 Ping，调用方可以限制连接耗时
+// - 任何一步失败都要关闭已创建的连接池，否则每次重连失败都会泄漏一个 *sql.DB
+func connect(ctx context.Context, cfg config.DatabaseConfig, instance config.DBInstanceConfig, log *logger.Logger) (*gorm.DB, error) {
+	dialector, err := dialector(cfg, instance)
+	if err != nil {
+		return nil, err
 	}
 
 	// 配置 GORM
+	// TranslateError：唯一键冲突、外键约束等驱动错误转换为 gorm.ErrDuplicatedKey 等，
+	// 由 errors.FromError 统一映射为业务错误，不需要判断各数据库的错误码
 	gormConfig := &gorm.Config{
-		Logger: NewGormLogger(log, cfg.LogLevel, cfg.SlowThreshold),
+		Logger:               NewGormLogger(log, cfg.LogLevel, cfg.SlowThreshold),
+		TranslateError:       true,
+		DisableAutomaticPing: true,
 	}
 
 	// 创建连接
 	db, err := gorm.Open(dialector, gormConfig)
 	if err != nil {
+		closeDB(db)
+		return nil, err
+	}
+
+	// 注册链路追踪回调（每条 SQL 生成一个 Span）
+	if err := registerTracing(db, cfg.Name, cfg.Type); err != nil {
+		closeDB(db)
+		return nil, err
+	}
+
+	// 验证连接（受 ctx 控制超时）
+	if err := pingDB(ctx, db); err != nil {
+		closeDB(db)
 		return nil, err
 	}
 
 	return db, nil
 }
 
+// closeDB 关闭 GORM 底层的连接池（db 为 nil 或没有连接池时跳过）
+func closeDB(db *gorm.DB) {
+	if db == nil {
+		return
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
+	}
+}
+
 // Master 获取主库连接（用于写操作）
 //
 // 初级工程师学习要点：
 // - 所有写操作（INSERT、UPDATE、DELETE）都应该使用主库
 // - 返回的是 GORM 的 DB 实例，可以直接进行数据库操作
+// - ctx 中有 Transaction 开启的事务时，返回该事务
 func (d *Database) Master(ctx context.Context) *gorm.DB {
+	if tx := d.txFromContext(ctx); tx != nil {
+		return tx.WithContext(ctx)
+	}
 	return d.master.WithContext(ctx)
 }
 
@@ -177,36 +233,53 @@ func (d *Database) Master(ctx context.Context) *gorm.DB {
 //
 // 初级工程师学习要点：
 // - 所有读操作（SELECT）都应该使用从库
-// - 如果没有从库，自动降级到主库
-// - 使用轮询算法在多个从库之间负载均衡
+// - 按 load_balance 策略在健康的从库之间负载均衡，跳过已剔除和延迟过大的从库
+// - 如果没有从库或所有从库都不可用，自动降级到主库
+// - 同一会话写入后的窗口期内读主库，保证能读到自己刚写入的数据
+// - ctx 中有 Transaction 开启的事务时，返回该事务（事务内的读必须看到事务内的写）
 func (d *Database) Slave(ctx context.Context) *gorm.DB {
-	// 如果没有从库，使用主库
-	if len(d.slaves) == 0 {
+	if tx := d.txFromContext(ctx); tx != nil {
+		return tx.WithContext(ctx)
+	}
+
+	// 刚写入过或显式要求时读主库（Read-Your-Writes）
+	if d.readFromMaster(ctx) {
 		return d.master.WithContext(ctx)
 	}
 
-	// 轮询选择从库
-	// 使用 atomic 操作保证并发安全
-	index := d.slaveIndex.Add(1) % uint32(len(d.slaves))
-	return d.slaves[index].WithContext(ctx)
+	// 收集可用的从库，按负载均衡策略选择
+	candidates := make([]candidate, 0, len(d.slaves))
+	for _, r := range d.slaves {
+		if slave := r.get(); slave != nil {
+			candidates = append(candidates, candidate{replica: r, db: slave})
+		}
+	}
+	if len(candidates) > 0 {
+		return d.balancer.pick(candidates).db.WithContext(ctx)
+	}
+
+	// 没有可用从库，使用主库
+	return d.master.WithContext(ctx)
 }
 
 // Close 关闭数据库连接
 //
 // 初级工程师学习要点：
 // - 应用退出时应该关闭数据库连接，释放资源
-// - 需要关闭主库和所有从库
+// - 先停止从库后台检查，再关闭主库和所有从库
 func (d *Database) Close() error {
-	// 关闭主库
-	if sqlDB, err := d.master.DB(); err == nil {
-		sqlDB.Close()
+	// 停止从库后台检查
+	if d.cancel != nil {
+		d.cancel()
+		d.wg.Wait()
 	}
 
+	// 关闭主库
+	closeDB(d.master)
+
 	// 关闭所有从库
-	for _, slave := range d.slaves {
-		if sqlDB, err := slave.DB(); err == nil {
-			sqlDB.Close()
-		}
+	for _, r := range d.slaves {
+		closeDB(r.conn())
 	}
 
 	return nil
@@ -217,6 +290,44 @@ func (d *Database) Name() string {
 	return d.name
 }
 
+// Type 返回数据库类型（mysql、postgres、sqlite）
+func (d *Database) Type() string {
+	return d.config.Type
+}
+
+// InstanceStats 单个连接（主库或从库）的连接池统计
+type InstanceStats struct {
+	Role     string        // master 或 slave
+	Instance string        // 实例标识，如 master、slave-0
+	Stats    sql.DBStats   // database/sql 连接池统计
+	Lag      time.Duration // 复制延迟（仅从库，配置了 max_lag 时才测量）
+}
+
+// Stats 返回主库和所有从库的连接池统计
+//
+// 初级工程师学习要点：
+// - sql.DBStats 包含打开连接数、使用中/空闲连接数、等待次数和等待时长
+// - 等待次数持续增长说明连接池不够用，需要调大 max_open_conns
+func (d *Database) Stats() []InstanceStats {
+	stats := make([]InstanceStats, 0, len(d.slaves)+1)
+
+	if sqlDB, err := d.master.DB(); err == nil {
+		stats = append(stats, InstanceStats{Role: "master", Instance: "master", Stats: sqlDB.Stats()})
+	}
+
+	for _, r := range d.slaves {
+		slave := r.conn()
+		if slave == nil {
+			continue
+		}
+		if sqlDB, err := slave.DB(); err == nil {
+			stats = append(stats, InstanceStats{Role: "slave", Instance: r.name, Stats: sqlDB.Stats(), Lag: r.replicationLag()})
+		}
+	}
+
+	return stats
+}
+
 // DatabaseHealthChecker 数据库健康检查器
 //
 // 初级工程师学习要点：
@@ -232,25 +343,6 @@ func (c *DatabaseHealthChecker) Name() string {
 	return c.name
 }
 
-// Ping 执行轻量级健康检查（用于 Liveness）
-//
-// 初级工程师学习要点：
-// - Ping 只检查数据库连接是否存活
-// - 用于 Kubernetes Liveness Probe
-func (c *DatabaseHealthChecker) Ping(ctx context.Context) error {
-	sqlDB, err := c.db.DB()
-	if err != nil {
-		return fmt.Errorf("failed to get sql.DB: %w", err)
-	}
-
-	// Ping 数据库
-	if err := sqlDB.PingContext(ctx); err != nil {
-		return fmt.Errorf("database ping failed: %w", err)
-	}
-
-	return nil
-}
-
 // Check 执行完整健康检查（用于 Readiness）
 //
 // 初级工程师学习要点：
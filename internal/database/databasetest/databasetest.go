@@ -0,0 +1,41 @@
+// Package databasetest 提供测试用的数据库
+//
+// 初级工程师学习要点：
+// - 测试使用临时目录中的 SQLite 文件，不依赖外部数据库，测试结束后自动关闭和删除
+// - 只在 _test.go 中导入，不会被编译进服务
+package databasetest
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jingpc/awesome-be/internal/config"
+	"github.com/jingpc/awesome-be/internal/database"
+	"github.com/jingpc/awesome-be/internal/logger"
+)
+
+// NewSQLite 创建临时 SQLite 数据库（名称为 test，不注册健康检查）
+//
+// 使用示例：
+//
+//	db := databasetest.NewSQLite(t)
+//	db.Master(ctx).AutoMigrate(&User{})
+func NewSQLite(t testing.TB) *database.Database {
+	t.Helper()
+
+	log, err := logger.New(config.LoggerConfig{Level: "error", Format: "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := database.New(config.DatabaseConfig{
+		Name:     "test",
+		Type:     "sqlite",
+		LogLevel: "silent",
+		Master:   config.DBInstanceConfig{Database: filepath.Join(t.TempDir(), "test.db")},
+	}, log, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
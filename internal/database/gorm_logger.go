@@ -105,7 +105,7 @@ func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql
 	switch {
 	case err != nil && l.logLevel >= gormlogger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
 		// SQL 执行错误
-		l.logger.Error("SQL execution error",
+		l.logger.ErrorContext(ctx, "SQL execution error",
 			zap.Error(err),
 			zap.Duration("elapsed", elapsed),
 			zap.String("sql", sql),
@@ -113,7 +113,7 @@ func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql
 		)
 	case elapsed > l.slowThreshold && l.slowThreshold != 0 && l.logLevel >= gormlogger.Warn:
 		// 慢查询
-		l.logger.Warn("Slow SQL query",
+		l.logger.WarnContext(ctx, "Slow SQL query",
 			zap.Duration("elapsed", elapsed),
 			zap.Duration("threshold", l.slowThreshold),
 			zap.String("sql", sql),
@@ -121,7 +121,7 @@ func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql
 		)
 	case l.logLevel >= gormlogger.Info:
 		// 正常 SQL 执行
-		l.logger.Debug("SQL execution",
+		l.logger.DebugContext(ctx, "SQL execution",
 			zap.Duration("elapsed", elapsed),
 			zap.String("sql", sql),
 			zap.Int64("rows", rows),
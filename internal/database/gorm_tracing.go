@@ -0,0 +1,119 @@
+// Package database GORM 链路追踪
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// tracerName GORM 回调使用的 Tracer 名称
+const tracerName = "github.com/jingpc/awesome-be/internal/database"
+
+// spanKey 是 Span 在 gorm.Statement 中的存储键
+const spanKey = "otel:span"
+
+// registerTracing 为 GORM 注册链路追踪回调
+//
+// 初级工程师学习要点：
+// - GORM 的每种操作（Create/Query/Update/Delete/Row/Raw）都有一条回调链
+// - 在链的开头创建 Span，在链的末尾结束 Span，中间就是 SQL 的执行过程
+// - Span 的父 Span 来自 db.WithContext(ctx) 传入的 Context（通常是 HTTP 请求的 Span）
+// - 与 GormLogger 配合：日志通过 Context 带上 trace_id/span_id，可以和 Span 互相关联
+func registerTracing(db *gorm.DB, dbName, dbType string) error {
+	t := &gormTracing{
+		tracer: otel.Tracer(tracerName),
+		attrs: []attribute.KeyValue{
+			attribute.String("db.system", dbSystem(dbType)),
+			attribute.String("db.name", dbName),
+		},
+	}
+
+	// "*" 表示排在所有回调之前/之后
+	cb := db.Callback()
+	errs := []error{
+		cb.Create().Before("*").Register("otel:before_create", t.before("create")),
+		cb.Create().After("*").Register("otel:after_create", t.after),
+		cb.Query().Before("*").Register("otel:before_query", t.before("query")),
+		cb.Query().After("*").Register("otel:after_query", t.after),
+		cb.Update().Before("*").Register("otel:before_update", t.before("update")),
+		cb.Update().After("*").Register("otel:after_update", t.after),
+		cb.Delete().Before("*").Register("otel:before_delete", t.before("delete")),
+		cb.Delete().After("*").Register("otel:after_delete", t.after),
+		cb.Row().Before("*").Register("otel:before_row", t.before("row")),
+		cb.Row().After("*").Register("otel:after_row", t.after),
+		cb.Raw().Before("*").Register("otel:before_raw", t.before("raw")),
+		cb.Raw().After("*").Register("otel:after_raw", t.after),
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("failed to register tracing callbacks: %w", err)
+	}
+
+	return nil
+}
+
+// gormTracing GORM 链路追踪回调
+type gormTracing struct {
+	tracer trace.Tracer
+	attrs  []attribute.KeyValue
+}
+
+// before 创建 Span
+func (t *gormTracing) before(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Statement == nil || db.Statement.Context == nil {
+			return
+		}
+
+		ctx, span := t.tracer.Start(db.Statement.Context, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(t.attrs...),
+			trace.WithAttributes(attribute.String("db.operation", operation)),
+		)
+
+		db.Statement.Context = ctx
+		db.InstanceSet(spanKey, span)
+	}
+}
+
+// after 结束 Span，记录 SQL 和执行结果
+func (t *gormTracing) after(db *gorm.DB) {
+	value, ok := db.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("db.statement", db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.RowsAffected),
+	)
+	if db.Statement.Table != "" {
+		span.SetAttributes(attribute.String("db.sql.table", db.Statement.Table))
+	}
+
+	// 记录不到数据不算错误
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
+
+// dbSystem 将配置中的数据库类型映射为 OpenTelemetry 约定的 db.system 值
+func dbSystem(dbType string) string {
+	switch dbType {
+	case "postgres":
+		return "postgresql"
+	default:
+		return dbType
+	}
+}
@@ -2,7 +2,9 @@
 package database
 
 import (
+	"context"
 	"fmt"
+	"sort"
 	"sync"
 
 	"github.com/jingpc/awesome-be/internal/config"
@@ -16,9 +18,17 @@ import (
 // - Manager 管理多个数据库实例
 // - 使用 map 存储，通过名称快速查找
 // - 使用 sync.RWMutex 保证并发安全
+// - 支持运行时热更新（Reload），替换下来的旧连接池由后台 goroutine 延迟关闭
 type Manager struct {
 	databases map[string]*Database
 	mu        sync.RWMutex
+
+	log       *logger.Logger
+	healthMgr *health.Manager
+	reloadMu  sync.Mutex      // 保证同一时间只有一次热更新
+	ctx       context.Context // Close 时取消，让等待中的旧连接池立即关闭
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup // 等待关闭的旧连接池
 }
 
 // NewManager 创建数据库管理器
@@ -30,12 +40,21 @@ type Manager struct {
 func NewManager(configs []config.DatabaseConfig, log *logger.Logger, healthMgr *health.Manager) (*Manager, error) {
 	mgr := &Manager{
 		databases: make(map[string]*Database),
+		log:       log,
+		healthMgr: healthMgr,
 	}
+	mgr.ctx, mgr.cancel = context.WithCancel(context.Background())
 
 	// 初始化所有数据库实例
 	for _, cfg := range configs {
 		db, err := New(cfg, log, healthMgr)
 		if err != nil {
+			// 关闭已经创建的数据库，和 Reload 失败时的处理一致
+			for _, created := range mgr.databases {
+				created.unregisterHealthCheckers(healthMgr)
+				created.Close()
+			}
+			mgr.cancel()
 			return nil, fmt.Errorf("failed to initialize database %s: %w", cfg.Name, err)
 		}
 
@@ -57,8 +76,33 @@ func (m *Manager) Get(name string) *Database {
 	return m.databases[name]
 }
 
+// List 返回所有数据库实例（按名称排序）
+//
+// 初级工程师学习要点：
+// - map 的遍历顺序是随机的，排序后输出稳定，便于监控指标和日志对比
+func (m *Manager) List() []*Database {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	list := make([]*Database, 0, len(m.databases))
+	for _, db := range m.databases {
+		list = append(list, db)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name() < list[j].Name()
+	})
+
+	return list
+}
+
 // Close 关闭所有数据库连接
+//
+// 初级工程师学习要点：
+// - 热更新替换下来、还在等待关闭的旧连接池会立即关闭
 func (m *Manager) Close() error {
+	m.cancel()
+	m.wg.Wait()
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -0,0 +1,166 @@
+// Package database 数据库连接热更新
+package database
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/jingpc/awesome-be/internal/config"
+)
+
+// 热更新的默认参数（未配置 reload 时使用）
+const (
+	defaultReloadGracePeriod   = 30 * time.Second
+	defaultReloadCheckInterval = time.Second
+)
+
+// Reload 使用新的配置热更新数据库连接
+//
+// 初级工程师学习要点：
+// - 配置没有变化的数据库保持不动，已有连接不受影响
+// - 配置变化的数据库先创建新连接池，全部成功后才一次性替换（任何一个失败都不会改变当前状态）
+// - 新配置中新增的数据库会被创建，删除的数据库会被移除
+// - 替换下来的旧连接池不会立即关闭，而是等待使用中的连接归还（最多 reload.grace_period）
+// - 超过 grace_period 后，reload.force_close 为 true 时强制关闭，否则继续等待连接归还
+// - 业务代码应该每次通过 Manager.Get 获取 Database，不要长期持有，才能用上新的连接池
+//
+// 使用示例（轮换数据库密码，无需重启 Pod）：
+//
+//	if err := dbMgr.Reload(newCfg.Databases); err != nil {
+//		log.Error("failed to reload databases", "error", err)
+//	}
+func (m *Manager) Reload(configs []config.DatabaseConfig) error {
+	m.reloadMu.Lock()
+	defer m.reloadMu.Unlock()
+
+	m.mu.RLock()
+	current := make(map[string]*Database, len(m.databases))
+	for name, db := range m.databases {
+		current[name] = db
+	}
+	m.mu.RUnlock()
+
+	// 1. 创建配置有变化和新增的数据库（此时不注册健康检查，替换成功后再注册）
+	next := make(map[string]*Database, len(configs))
+	var created []*Database
+	for _, cfg := range configs {
+		if old, ok := current[cfg.Name]; ok && reflect.DeepEqual(old.config, cfg) {
+			next[cfg.Name] = old
+			continue
+		}
+
+		db, err := New(cfg, m.log, nil)
+		if err != nil {
+			for _, c := range created {
+				c.Close()
+			}
+			return fmt.Errorf("failed to initialize database %s: %w", cfg.Name, err)
+		}
+		next[cfg.Name] = db
+		created = append(created, db)
+	}
+
+	// 2. 原子替换
+	m.mu.Lock()
+	m.databases = next
+	m.mu.Unlock()
+
+	// 3. 更新健康检查，延迟关闭旧连接池
+	for name, old := range current {
+		if next[name] == old {
+			continue
+		}
+
+		old.unregisterHealthCheckers(m.healthMgr)
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			m.retire(old)
+		}()
+
+		if _, ok := next[name]; !ok {
+			m.log.Info("database removed", "name", name)
+		}
+	}
+	for _, db := range created {
+		db.registerHealthCheckers(m.healthMgr)
+		if _, ok := current[db.Name()]; ok {
+			m.log.Info("database reloaded", "name", db.Name())
+		} else {
+			m.log.Info("database added", "name", db.Name())
+		}
+	}
+
+	return nil
+}
+
+// retire 宽限期结束后关闭旧连接池
+//
+// 架构思路：
+// - 替换前拿到旧 *Database 的请求还可能发起新的查询，所以旧连接池至少保留 GracePeriod
+// - 宽限期内即使没有使用中的连接也不关闭
+// - 宽限期结束后：ForceClose 为 true 时立即关闭，否则等使用中的连接归还后再关闭
+// - 服务关闭时（m.ctx 取消）不再等待，直接关闭
+func (m *Manager) retire(db *Database) {
+	cfg := db.config.Reload
+	grace := cfg.GracePeriod
+	if grace <= 0 {
+		grace = defaultReloadGracePeriod
+	}
+	interval := cfg.CheckInterval
+	if interval <= 0 {
+		interval = defaultReloadCheckInterval
+	}
+
+	deadline := time.NewTimer(grace)
+	defer deadline.Stop()
+	select {
+	case <-m.ctx.Done():
+		db.Close()
+		return
+	case <-deadline.C:
+	}
+
+	if cfg.ForceClose {
+		if inUse := db.inUse(); inUse > 0 {
+			m.log.Warn("old database pool force closed after grace period", "name", db.Name(), "in_use", inUse)
+		} else {
+			m.log.Info("old database pool closed", "name", db.Name())
+		}
+		db.Close()
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for warned := false; ; {
+		inUse := db.inUse()
+		if inUse == 0 {
+			db.Close()
+			m.log.Info("old database pool closed", "name", db.Name())
+			return
+		}
+		if !warned {
+			m.log.Warn("old database pool still in use after grace period", "name", db.Name(), "in_use", inUse)
+			warned = true
+		}
+
+		select {
+		case <-m.ctx.Done():
+			db.Close()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// inUse 返回主库和所有从库使用中的连接总数
+func (d *Database) inUse() int {
+	total := 0
+	for _, s := range d.Stats() {
+		total += s.Stats.InUse
+	}
+	return total
+}
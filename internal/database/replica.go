@@ -0,0 +1,331 @@
+// Package database 从库健康跟踪
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/jingpc/awesome-be/internal/config"
+	"github.com/jingpc/awesome-be/internal/health"
+	"github.com/jingpc/awesome-be/internal/logger"
+)
+
+// 从库检查的默认参数（未配置 health_check 时使用）
+const (
+	defaultReplicaCheckInterval = 10 * time.Second
+	defaultReplicaCheckTimeout  = 5 * time.Second
+)
+
+// errReplicaNotConnected 从库尚未建立连接
+var errReplicaNotConnected = errors.New("replica not connected")
+
+// replica 从库实例
+//
+// 初级工程师学习要点：
+// - 每个从库单独记录连接和健康状态
+// - 启动时连接失败的从库也会保留，由后台检查负责重连
+// - 只有健康且复制延迟未超过 max_lag 的从库参与读请求的轮询
+type replica struct {
+	name   string // 实例标识，如 slave-0
+	config config.DBInstanceConfig
+	weight int // 负载均衡权重（至少为 1）
+
+	currentWeight int // 平滑加权轮询的当前权重（由 weightedBalancer 加锁维护）
+
+	mu       sync.RWMutex
+	db       *gorm.DB      // 未连接时为 nil
+	healthy  bool          // 连接是否正常
+	err      error         // 最近一次检查的错误
+	failures int           // 连续失败次数
+	lag      time.Duration // 最近一次测得的复制延迟
+	lagging  bool          // 延迟是否超过 max_lag
+}
+
+// get 返回可用的从库连接，不健康或延迟过大时返回 nil
+func (r *replica) get() *gorm.DB {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if !r.healthy || r.lagging {
+		return nil
+	}
+	return r.db
+}
+
+// conn 返回从库连接（不论是否健康），未连接时返回 nil
+func (r *replica) conn() *gorm.DB {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.db
+}
+
+// status 返回最近一次检查的错误
+func (r *replica) status() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.db == nil && r.err == nil {
+		return errReplicaNotConnected
+	}
+	if r.err == nil && r.lagging {
+		return fmt.Errorf("replication lag %s exceeds max_lag", r.lag)
+	}
+	return r.err
+}
+
+// replicationLag 返回最近一次测得的复制延迟
+func (r *replica) replicationLag() time.Duration {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.lag
+}
+
+// monitorReplicas 后台检查所有从库，剔除不健康的从库，恢复后重新加入
+//
+// 初级工程师学习要点：
+// - 使用 health_check 中的 interval/timeout/retries（未配置时使用默认值）
+// - 连续失败 retries 次才剔除，避免网络抖动导致从库频繁进出
+// - 恢复只需要一次成功，尽快分担主库的读压力
+// - 配置了 replication.max_lag 时同时测量复制延迟，延迟过大的从库暂不参与读请求
+// - 无论 health_check.enabled 是否开启都会执行，因为它决定的是读请求路由
+func (d *Database) monitorReplicas(ctx context.Context, log *logger.Logger) {
+	interval := d.config.HealthCheck.Interval
+	if interval <= 0 {
+		interval = defaultReplicaCheckInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, r := range d.slaves {
+				d.checkReplica(ctx, r, log)
+			}
+		}
+	}
+}
+
+// checkReplica 检查单个从库，必要时重连
+func (d *Database) checkReplica(ctx context.Context, r *replica, log *logger.Logger) {
+	timeout := d.config.HealthCheck.Timeout
+	if timeout <= 0 {
+		timeout = defaultReplicaCheckTimeout
+	}
+	retries := max(d.config.HealthCheck.Retries, 1)
+
+	// 重连和 Ping 共用同一个超时，一个不可达的从库不会拖住其他从库的检查
+	checkCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// 1. 未连接的从库先尝试重连
+	db := r.conn()
+	var err error
+	if db == nil {
+		db, err = d.connectReplica(checkCtx, r.config, log)
+		if err == nil {
+			r.mu.Lock()
+			r.db = db
+			r.mu.Unlock()
+		}
+	}
+
+	// 2. Ping 检查，并测量复制延迟
+	var lag time.Duration
+	if err == nil {
+		err = pingDB(checkCtx, db)
+		if err == nil && d.config.Replication.MaxLag > 0 {
+			lag, err = measureLag(checkCtx, db, d.config.Type)
+		}
+	}
+
+	// 3. 更新状态
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.err = err
+	if err == nil && d.config.Replication.MaxLag > 0 {
+		r.lag = lag
+		lagging := lag > d.config.Replication.MaxLag
+		if lagging != r.lagging {
+			r.lagging = lagging
+			if lagging {
+				log.Warn("replica skipped due to replication lag", "database", d.name, "replica", r.name, "lag", lag.String())
+			} else {
+				log.Info("replica caught up", "database", d.name, "replica", r.name, "lag", lag.String())
+			}
+		}
+	}
+	if err != nil {
+		r.failures++
+		if r.healthy && r.failures >= retries {
+			r.healthy = false
+			log.Warn("replica ejected from read rotation", "database", d.name, "replica", r.name, "error", err)
+		}
+		return
+	}
+
+	r.failures = 0
+	if !r.healthy {
+		r.healthy = true
+		log.Info("replica admitted to read rotation", "database", d.name, "replica", r.name)
+	}
+}
+
+// connectReplica 建立从库连接并配置连接池
+func (d *Database) connectReplica(ctx context.Context, instance config.DBInstanceConfig, log *logger.Logger) (*gorm.DB, error) {
+	db, err := connect(ctx, d.config, instance, log)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := configurePool(db, d.config); err != nil {
+		closeDB(db)
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// pingDB 检查连接是否可用
+func pingDB(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get sql.DB: %w", err)
+	}
+
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return fmt.Errorf("database ping failed: %w", err)
+	}
+
+	return nil
+}
+
+// measureLag 测量从库的复制延迟
+//
+// 初级工程师学习要点：
+// - MySQL：SHOW REPLICA STATUS 的 Seconds_Behind_Source（8.0.22 之前为 SHOW SLAVE STATUS 的 Seconds_Behind_Master）
+// - PostgreSQL：当前时间减去最后一次回放事务的时间；WAL 已全部回放时延迟为 0（避免主库空闲时误判）
+// - SQLite 没有复制，延迟始终为 0
+// - 复制线程停止时 MySQL 返回 NULL，视为错误，从库会被剔除
+func measureLag(ctx context.Context, db *gorm.DB, dbType string) (time.Duration, error) {
+	switch dbType {
+	case "mysql":
+		return measureMySQLLag(ctx, db)
+
+	case "postgres":
+		var seconds float64
+		err := db.WithContext(ctx).Raw(`SELECT CASE
+			WHEN NOT pg_is_in_recovery() THEN 0
+			WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+			ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+		END`).Scan(&seconds).Error
+		if err != nil {
+			return 0, fmt.Errorf("failed to measure replication lag: %w", err)
+		}
+		return time.Duration(seconds * float64(time.Second)), nil
+
+	default:
+		return 0, nil
+	}
+}
+
+// measureMySQLLag 读取 MySQL 从库状态中的延迟秒数
+func measureMySQLLag(ctx context.Context, db *gorm.DB) (time.Duration, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get sql.DB: %w", err)
+	}
+
+	rows, err := sqlDB.QueryContext(ctx, "SHOW REPLICA STATUS")
+	if err != nil {
+		// MySQL 8.0.22 之前的版本
+		rows, err = sqlDB.QueryContext(ctx, "SHOW SLAVE STATUS")
+		if err != nil {
+			return 0, fmt.Errorf("failed to measure replication lag: %w", err)
+		}
+	}
+	defer rows.Close()
+
+	// 不是从库（没有复制状态）
+	if !rows.Next() {
+		return 0, rows.Err()
+	}
+
+	// 列数随版本变化，按列名查找
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	values := make([]sql.RawBytes, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return 0, fmt.Errorf("failed to scan replica status: %w", err)
+	}
+
+	for i, column := range columns {
+		if column != "Seconds_Behind_Source" && column != "Seconds_Behind_Master" {
+			continue
+		}
+		if values[i] == nil {
+			return 0, errors.New("replication is not running")
+		}
+		seconds, err := strconv.ParseInt(string(values[i]), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid replication lag %q: %w", values[i], err)
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+
+	return 0, errors.New("replication lag column not found")
+}
+
+// ReplicaHealthChecker 从库健康检查器
+//
+// 初级工程师学习要点：
+// - 实现 health.HealthChecker 接口，让就绪探针展示每个从库的状态
+// - 不直接访问数据库，只读取后台检查的结果（探针不会打到从库）
+// - 以非关键组件注册：从库全部不可用时读请求会降级到主库，服务仍然可用
+type ReplicaHealthChecker struct {
+	name    string
+	replica *replica
+}
+
+// Name 返回检查器名称（数据库名/从库标识）
+func (c *ReplicaHealthChecker) Name() string {
+	return c.name
+}
+
+// Check 返回从库最近一次检查的结果
+func (c *ReplicaHealthChecker) Check(ctx context.Context) error {
+	return c.replica.status()
+}
+
+// registerReplicaCheckers 为每个从库注册健康检查器
+func (d *Database) registerReplicaCheckers(healthMgr *health.Manager) {
+	for _, r := range d.slaves {
+		checker := &ReplicaHealthChecker{
+			name:    d.name + "/" + r.name,
+			replica: r,
+		}
+		// 结果由 monitorReplicas 维护，这里不再启用 health.Manager 的后台检查
+		healthMgr.Register(checker, config.HealthCheckConfig{
+			Criticality: health.CriticalityNonCritical,
+		})
+	}
+}
@@ -0,0 +1,169 @@
+// Package database 读写一致性（Read-Your-Writes）
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// sessionKey 是 Session 在 Context 中的存储键
+type sessionKey struct{}
+
+// masterKey 是强制读主库标记在 Context 中的存储键
+type masterKey struct{}
+
+// Session 记录一次请求（或会话）中各数据库最后一次写入的时间
+//
+// 初级工程师学习要点：
+// - 主从复制有延迟，写入主库后立刻从从库读取，可能读到旧数据
+// - 写入成功后在 Session 中记录时间，窗口期内 Database.Slave 会返回主库
+// - Session 通过 Context 传递，由 HTTP 中间件为每个请求创建
+//
+// 使用示例：
+//
+//	ctx = database.WithSession(ctx, database.NewSession())
+//	db.Master(ctx).Create(&order)     // 写入成功后自动记录
+//	db.Slave(ctx).First(&order, id)   // 窗口期内自动读主库
+type Session struct {
+	mu       sync.RWMutex
+	writes   map[string]time.Time // 数据库名 -> 最后写入时间
+	restored time.Time            // 从上一个请求恢复的写入时间（对所有数据库生效）
+}
+
+// NewSession 创建会话
+func NewSession() *Session {
+	return &Session{writes: make(map[string]time.Time)}
+}
+
+// Restore 恢复上一个请求的最后写入时间（用于跨请求保持读主库）
+//
+// 初级工程师学习要点：
+// - 未来的时间视为当前时间，避免客户端伪造一个很远的时间长期占用主库
+func (s *Session) Restore(t time.Time) {
+	if now := time.Now(); t.After(now) {
+		t = now
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if t.After(s.restored) {
+		s.restored = t
+	}
+}
+
+// MarkWrite 记录对指定数据库的写入
+//
+// 初级工程师学习要点：
+// - 由 GORM 回调调用，可能在业务代码启动的任意 goroutine 中执行，所以这里只记录时间
+func (s *Session) MarkWrite(database string) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.writes[database] = now
+}
+
+// LastWrite 返回指定数据库的最后写入时间
+func (s *Session) LastWrite(database string) time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	last := s.writes[database]
+	if s.restored.After(last) {
+		last = s.restored
+	}
+	return last
+}
+
+// LatestWrite 返回本会话中最后一次写入的时间（任一数据库，不包括恢复的时间），没有写入时返回零值
+func (s *Session) LatestWrite() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var latest time.Time
+	for _, t := range s.writes {
+		if t.After(latest) {
+			latest = t
+		}
+	}
+	return latest
+}
+
+// WithSession 将会话存入 Context
+func WithSession(ctx context.Context, s *Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, s)
+}
+
+// SessionFromContext 从 Context 中获取会话，不存在时返回 nil
+func SessionFromContext(ctx context.Context) *Session {
+	s, _ := ctx.Value(sessionKey{}).(*Session)
+	return s
+}
+
+// WithMaster 标记该 Context 下的读请求强制走主库
+//
+// 初级工程师学习要点：
+// - 用于对一致性要求极高的场景（如支付结果查询），不依赖时间窗口
+//
+// 使用示例：
+//
+//	db.Slave(database.WithMaster(ctx)).First(&payment, id)
+func WithMaster(ctx context.Context) context.Context {
+	return context.WithValue(ctx, masterKey{}, true)
+}
+
+// readFromMaster 判断读请求是否应该走主库
+func (d *Database) readFromMaster(ctx context.Context) bool {
+	if force, _ := ctx.Value(masterKey{}).(bool); force {
+		return true
+	}
+
+	window := d.config.Replication.ReadYourWritesWindow
+	if window <= 0 {
+		return false
+	}
+
+	s := SessionFromContext(ctx)
+	if s == nil {
+		return false
+	}
+
+	last := s.LastWrite(d.name)
+	return !last.IsZero() && time.Since(last) < window
+}
+
+// registerWriteTracking 为主库注册写入记录回调
+//
+// 初级工程师学习要点：
+// - 在 Create/Update/Delete/Exec 执行成功后，把写入时间记录到 Context 中的 Session
+// - 没有 Session 的 Context（如后台任务）不记录
+func registerWriteTracking(db *gorm.DB, dbName string) error {
+	mark := func(tx *gorm.DB) {
+		if tx.Error != nil || tx.Statement.Context == nil {
+			return
+		}
+		if s := SessionFromContext(tx.Statement.Context); s != nil {
+			s.MarkWrite(dbName)
+		}
+	}
+
+	cb := db.Callback()
+	err := errors.Join(
+		cb.Create().After("*").Register("rw:after_create", mark),
+		cb.Update().After("*").Register("rw:after_update", mark),
+		cb.Delete().After("*").Register("rw:after_delete", mark),
+		cb.Raw().After("*").Register("rw:after_raw", mark),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to register write tracking callbacks: %w", err)
+	}
+
+	return nil
+}
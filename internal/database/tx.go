@@ -0,0 +1,142 @@
+// Package database 事务管理
+package database
+
+import (
+	"context"
+	"database/sql"
+	"math/rand/v2"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/gorm"
+
+	"github.com/jingpc/awesome-be/pkg/errors"
+)
+
+// 事务重试的默认参数（未配置 transaction 时使用）
+const (
+	defaultTxMaxAttempts  = 3
+	defaultTxRetryBackoff = 20 * time.Millisecond
+	maxTxRetryBackoff     = time.Second
+)
+
+// txKey 是事务在 Context 中的存储键（按数据库名区分，不同数据库的事务互不影响）
+type txKey struct {
+	db string
+}
+
+// Transaction 在事务中执行 fn
+//
+// 初级工程师学习要点：
+// - 事务存放在 Context 中，fn 内部调用的 Master(ctx)/Slave(ctx) 自动使用同一个事务
+// - 服务之间只需要传递 ctx，不需要把 *gorm.DB 作为参数层层传递
+// - fn 返回错误或 panic 时回滚，否则提交
+// - 嵌套调用（ctx 中已经有事务）使用 SAVEPOINT：内层失败只回滚内层，外层可以决定是否继续
+// - 最外层事务遇到死锁、序列化失败时自动重试（指数退避 + 随机抖动），嵌套调用不重试
+// - 因为可能重试，fn 必须可以安全地重复执行（不要在 fn 中发送消息、调用外部接口）
+// - 业务错误（*errors.Error）原样返回，其他错误包装为 ErrDBTxError
+//
+// 使用示例：
+//
+//	err := db.Transaction(ctx, func(ctx context.Context) error {
+//		if err := db.Master(ctx).Create(&order).Error; err != nil {
+//			return err
+//		}
+//		return stockService.Deduct(ctx, order.ProductID, order.Quantity) // 加入同一个事务
+//	})
+func (d *Database) Transaction(ctx context.Context, fn func(ctx context.Context) error, opts ...*sql.TxOptions) error {
+	// 嵌套调用：加入外层事务，GORM 自动使用 SAVEPOINT
+	if tx := d.txFromContext(ctx); tx != nil {
+		err := tx.Transaction(func(tx *gorm.DB) error {
+			return fn(d.withTx(ctx, tx))
+		})
+		return wrapTxError(err)
+	}
+
+	maxAttempts := d.config.Transaction.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultTxMaxAttempts
+	}
+	backoff := d.config.Transaction.RetryBackoff
+	if backoff <= 0 {
+		backoff = defaultTxRetryBackoff
+	}
+	backoff = min(backoff, maxTxRetryBackoff)
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = d.master.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return fn(d.withTx(ctx, tx))
+		}, opts...)
+		if err == nil || attempt >= maxAttempts || !isRetryableTxError(err) {
+			break
+		}
+
+		// 指数退避，加入随机抖动避免冲突的事务同时重试再次冲突
+		wait := backoff/2 + rand.N(backoff/2+1)
+		select {
+		case <-ctx.Done():
+			return errors.ErrDBTxError.WithError(ctx.Err())
+		case <-time.After(wait):
+		}
+		// 达到上限后不再翻倍（max_attempts 很大时按移位计算会溢出成负数）
+		backoff = min(backoff*2, maxTxRetryBackoff)
+	}
+
+	return wrapTxError(err)
+}
+
+// withTx 将事务存入 Context
+func (d *Database) withTx(ctx context.Context, tx *gorm.DB) context.Context {
+	return context.WithValue(ctx, txKey{db: d.name}, tx)
+}
+
+// txFromContext 从 Context 中获取当前数据库的事务，不存在时返回 nil
+func (d *Database) txFromContext(ctx context.Context) *gorm.DB {
+	tx, _ := ctx.Value(txKey{db: d.name}).(*gorm.DB)
+	return tx
+}
+
+// wrapTxError 包装事务错误，业务错误原样返回
+func wrapTxError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var e *errors.Error
+	if errors.As(err, &e) {
+		return err
+	}
+
+	return errors.ErrDBTxError.WithError(err)
+}
+
+// isRetryableTxError 判断事务错误是否可以重试
+//
+// 初级工程师学习要点：
+// - MySQL：1213 死锁、1205 锁等待超时
+// - PostgreSQL：40001 序列化失败、40P01 死锁
+// - SQLite：数据库文件或表被锁定
+// - 数据库不一定回滚了整个事务：MySQL 的 1205 默认（innodb_rollback_on_timeout=OFF）只回滚出错的语句
+// - 重试是安全的，是因为 fn 返回错误后 GORM 会回滚整个事务，下一次尝试在新事务中从头执行
+// - 所以 fn 必须把这些错误返回出来，吞掉错误继续执行会提交一个只完成了一部分的事务
+func isRetryableTxError(err error) bool {
+	var mysqlErr *mysqldriver.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == 1213 || mysqlErr.Number == 1205
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "40001" || pgErr.Code == "40P01"
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+	}
+
+	return false
+}
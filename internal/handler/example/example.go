@@ -5,6 +5,7 @@
 // - 演示各种错误响应
 // - 演示 Panic 恢复
 // - 演示数据库错误处理
+// - 演示分页查询
 //
 // 初级工程师学习要点：
 // - Handler 层负责处理 HTTP 请求和响应
@@ -20,6 +21,7 @@ import (
 	"github.com/jingpc/awesome-be/internal/logger"
 	"github.com/jingpc/awesome-be/internal/service/example"
 	"github.com/jingpc/awesome-be/pkg/errors"
+	"github.com/jingpc/awesome-be/pkg/middleware"
 	"github.com/jingpc/awesome-be/pkg/response"
 )
 
@@ -97,7 +99,7 @@ func (h *Handler) Panic(c *gin.Context) {
 // - 错误在 Handler 层统一处理和记录
 func (h *Handler) DBError(c *gin.Context) {
 	// 调用 Service 层
-	err := h.service.GetUser(c.Request.Context(), 999)
+	user, err := h.service.GetUser(c.Request.Context(), 999)
 	if err != nil {
 		// Handler 层记录日志
 		h.logger.Error("failed to get user",
@@ -112,9 +114,27 @@ func (h *Handler) DBError(c *gin.Context) {
 	}
 
 	// 正常情况下返回数据
-	response.Success(c, gin.H{
-		"message": "user found",
-	})
+	response.Success(c, user)
+}
+
+// ListUsers 分页查询示例
+//
+// 初级工程师学习要点：
+// - 查询参数直接交给 Service，由 Repository 按白名单解析
+// - 参数错误返回 400，数据库未配置返回数据库错误
+// - 如：GET /api/v1/examples/users?name[like]=tom&sort=-created_at&page=2
+func (h *Handler) ListUsers(c *gin.Context) {
+	page, err := h.service.ListUsers(c.Request.Context(), c.Request.URL.Query())
+	if err != nil {
+		h.logger.Warn("failed to list users",
+			zap.Error(err),
+			zap.String("path", c.Request.URL.Path),
+		)
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, page)
 }
 
 // NotFound 404 错误示例
@@ -130,3 +150,22 @@ func (h *Handler) NotFound(c *gin.Context) {
 
 	response.Error(c, errors.ErrNotFound.WithDetail("the requested resource does not exist"))
 }
+
+// Me JWT 认证示例
+//
+// 初级工程师学习要点：
+// - 路由上挂载了 middleware.JWTAuth，未携带有效令牌的请求不会到达这里
+// - 使用 middleware.GetClaims 获取当前用户信息
+func (h *Handler) Me(c *gin.Context) {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		response.Error(c, errors.ErrUnauthorized)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"subject":    claims.Subject,
+		"expires_at": claims.ExpiresAt.Unix(),
+		"extra":      claims.Extra,
+	})
+}
@@ -0,0 +1,154 @@
+// Package health 后台健康检查
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jingpc/awesome-be/internal/config"
+)
+
+// defaultCheckInterval 未配置 interval 时的后台检查间隔
+const defaultCheckInterval = 30 * time.Second
+
+// entry 已注册的检查器及其后台检查状态
+//
+// 初级工程师学习要点：
+// - 每个检查器一个 goroutine，按自己的 interval 定时执行 Check
+// - 结果缓存在 state 中，探针只读缓存，打到数据库的检查请求固定为每个 interval 一次
+// - 未启用后台检查的检查器同样记录最近一次实时检查的结果，供 Cached 读取
+// - 不管扩容多少 Pod、探测多频繁，都不会形成对数据库的探测风暴
+// - retries 是连续失败阈值：偶发的一次超时不会让服务立刻变为不可用
+type entry struct {
+	checker    HealthChecker
+	critical   bool          // 是否为关键组件
+	background bool          // 是否启用后台检查
+	interval   time.Duration // 检查间隔
+	timeout    time.Duration // 单次检查超时
+	retries    int           // 连续失败多少次后标记为 error
+
+	mu    sync.RWMutex
+	state checkState
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// checkState 检查的缓存状态
+type checkState struct {
+	err                 error     // 最近一次检查的错误
+	lastCheck           time.Time // 最近一次检查时间
+	lastSuccess         time.Time // 最近一次成功时间
+	consecutiveFailures int       // 连续失败次数
+}
+
+// newEntry 创建检查器条目，未配置的参数使用默认值
+func newEntry(checker HealthChecker, cfg config.HealthCheckConfig, defaultTimeout time.Duration) *entry {
+	e := &entry{
+		checker:    checker,
+		critical:   cfg.Criticality != CriticalityNonCritical,
+		background: cfg.Enabled,
+		interval:   cfg.Interval,
+		timeout:    cfg.Timeout,
+		retries:    cfg.Retries,
+	}
+
+	if e.interval <= 0 {
+		e.interval = defaultCheckInterval
+	}
+	if e.timeout <= 0 {
+		e.timeout = defaultTimeout
+	}
+	if e.retries <= 0 {
+		e.retries = 1
+	}
+
+	e.ctx, e.cancel = context.WithCancel(context.Background())
+	return e
+}
+
+// loop 按间隔定时执行检查，直到 stop 被调用
+func (e *entry) loop() {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.ctx.Done():
+			return
+		case <-ticker.C:
+			e.run()
+		}
+	}
+}
+
+// run 执行一次检查并更新缓存状态
+func (e *entry) run() {
+	ctx, cancel := context.WithTimeout(e.ctx, e.timeout)
+	defer cancel()
+
+	e.record(e.checker.Check(ctx))
+}
+
+// record 记录一次检查的结果（后台检查和实时检查共用）
+func (e *entry) record(err error) {
+	now := time.Now()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.state.err = err
+	e.state.lastCheck = now
+	if err != nil {
+		e.state.consecutiveFailures++
+	} else {
+		e.state.consecutiveFailures = 0
+		e.state.lastSuccess = now
+	}
+}
+
+// checked 返回是否已经检查过
+func (e *entry) checked() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	return !e.state.lastCheck.IsZero()
+}
+
+// stop 停止后台检查
+func (e *entry) stop() {
+	e.cancel()
+}
+
+// result 根据缓存状态生成检查结果
+//
+// 初级工程师学习要点：
+// - 从未成功过的检查器直接视为 error（启动时依赖不可用，不应该接收流量）
+// - 曾经成功过的检查器，连续失败次数达到 retries 才视为 error
+// - 实时检查的检查器没有阈值，最近一次失败就是 error（与探针返回的结果一致）
+func (e *entry) result() CheckResult {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	result := CheckResult{
+		Status:              StatusOK,
+		Critical:            e.critical,
+		ConsecutiveFailures: e.state.consecutiveFailures,
+	}
+	if !e.state.lastCheck.IsZero() {
+		result.LastCheck = e.state.lastCheck.Format(time.RFC3339)
+	}
+	if !e.state.lastSuccess.IsZero() {
+		result.LastSuccess = e.state.lastSuccess.Format(time.RFC3339)
+	}
+
+	if e.state.err != nil {
+		result.Message = e.state.err.Error()
+		if !e.background || e.state.lastSuccess.IsZero() || e.state.consecutiveFailures >= e.retries {
+			result.Status = StatusError
+		}
+	}
+
+	return result
+}
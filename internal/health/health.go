@@ -1,7 +1,7 @@
 // Package health 提供健康检查功能
 //
 // 健康检查模块为 GoFast 框架提供标准的 HTTP 健康检查端点，
-// 支持 Kubernetes 的存活探针（Liveness Probe）和就绪探针（Readiness Probe）。
+// 支持 Kubernetes 的存活探针（Liveness Probe）、就绪探针（Readiness Probe）和启动探针（Startup Probe）。
 //
 // 初级工程师学习要点：
 // - 理解健康检查在微服务中的重要性
@@ -12,25 +12,36 @@ package health
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jingpc/awesome-be/internal/config"
 )
 
+// 健康状态
+const (
+	StatusOK       = "ok"       // 全部正常
+	StatusDegraded = "degraded" // 只有非关键组件异常，仍然可以接收流量
+	StatusError    = "error"    // 关键组件异常
+)
+
+// 组件关键程度
+const (
+	CriticalityCritical    = "critical"     // 关键组件：失败时整体状态为 error（默认）
+	CriticalityNonCritical = "non_critical" // 非关键组件：失败时整体状态为 degraded
+)
+
 // HealthChecker 定义健康检查器接口
 //
 // 初级工程师学习要点：
 // - 接口定义了一组方法，任何实现这些方法的类型都满足该接口
 // - 这样可以让不同的组件（Database、Redis）实现统一的健康检查
+// - 只有就绪探针调用检查器，存活探针不检查依赖（依赖故障不应导致重启）
 type HealthChecker interface {
 	// Name 返回检查器名称（使用配置中的 name 字段）
 	Name() string
 
-	// Ping 执行轻量级检查（用于 Liveness）
-	// 只检查连接是否存活，不检查功能是否正常
-	Ping(ctx context.Context) error
-
 	// Check 执行完整检查（用于 Readiness）
 	// 检查服务是否完全就绪，可以处理请求
 	Check(ctx context.Context) error
@@ -41,16 +52,20 @@ type HealthChecker interface {
 // 初级工程师学习要点：
 // - Manager 使用 map 存储所有注册的健康检查器
 // - 使用 sync.RWMutex 保证并发安全（多个 goroutine 可以同时访问）
+// - 启用后台检查的检查器由独立的 goroutine 定时检查，探针直接读取缓存结果
 type Manager struct {
-	checkers map[string]HealthChecker
-	mu       sync.RWMutex
-	config   config.HealthConfig
+	checkers     map[string]*entry
+	mu           sync.RWMutex
+	config       config.HealthConfig
+	shuttingDown atomic.Bool // 是否正在优雅关闭（就绪探针返回 503）
+	started      atomic.Bool // 启动探针是否已经通过（通过后不再检查）
+	wg           sync.WaitGroup
 }
 
 // NewManager 创建健康检查管理器
 func NewManager(cfg config.HealthConfig) *Manager {
 	return &Manager{
-		checkers: make(map[string]HealthChecker),
+		checkers: make(map[string]*entry),
 		config:   cfg,
 	}
 }
@@ -60,34 +75,115 @@ func NewManager(cfg config.HealthConfig) *Manager {
 // 初级工程师学习要点：
 // - 这个方法会被 Database、Redis 等模块调用，自动注册健康检查
 // - 使用写锁（Lock）保证并发安全
-func (m *Manager) Register(checker HealthChecker) error {
+// - cfg.Enabled 为 true 时立即执行一次检查，然后按 cfg.Interval 在后台定时检查
+// - cfg.Enabled 为 false 时不启动后台检查，探针请求时实时检查
+// - 同名检查器重复注册时，旧的后台检查会先停止（用于热更新替换实例）
+func (m *Manager) Register(checker HealthChecker, cfg config.HealthCheckConfig) error {
+	e := newEntry(checker, cfg, m.config.Timeout)
+
+	// 第一次检查在加锁之前同步执行，注册完成后立即有结果可读
+	if e.background {
+		e.run()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if old, ok := m.checkers[checker.Name()]; ok {
+		old.stop()
+	}
+	m.checkers[checker.Name()] = e
+
+	if e.background {
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			e.loop()
+		}()
+	}
+
+	return nil
+}
+
+// Unregister 注销健康检查器并停止其后台检查
+//
+// 初级工程师学习要点：
+// - 用于热更新时移除已删除的组件，避免就绪探针一直报告已关闭的连接
+func (m *Manager) Unregister(name string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	m.checkers[checker.Name()] = checker
+	if e, ok := m.checkers[name]; ok {
+		e.stop()
+		delete(m.checkers, name)
+	}
+}
+
+// Close 停止所有后台检查（用于优雅关闭流程）
+//
+// 初级工程师学习要点：
+// - 应在关闭数据库、Redis 之前调用，避免后台检查访问已关闭的连接
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	for _, e := range m.checkers {
+		e.stop()
+	}
+	m.mu.Unlock()
+
+	m.wg.Wait()
 	return nil
 }
 
+// MarkShuttingDown 标记服务正在关闭
+//
+// 初级工程师学习要点：
+// - 收到退出信号后首先调用，让就绪探针立即返回 503
+// - Kubernetes 会将 Pod 从 Service 中摘除，不再转发新请求
+// - 存活探针不受影响，避免关闭过程中被重启
+func (m *Manager) MarkShuttingDown() {
+	m.shuttingDown.Store(true)
+}
+
+// IsShuttingDown 返回服务是否正在关闭
+func (m *Manager) IsShuttingDown() bool {
+	return m.shuttingDown.Load()
+}
+
+// IsStarted 返回启动探针是否已经通过
+func (m *Manager) IsStarted() bool {
+	return m.started.Load()
+}
+
 // CheckResult 单个检查器的检查结果
+//
+// 初级工程师学习要点：
+// - 后台检查的结果会带上最后检查时间和连续失败次数，便于判断故障持续了多久
 type CheckResult struct {
-	Status  string `json:"status"`            // "ok" 或 "error"
-	Message string `json:"message,omitempty"` // 错误信息（如果有）
+	Status              string `json:"status"`                         // "ok" 或 "error"
+	Critical            bool   `json:"critical"`                       // 是否为关键组件
+	Message             string `json:"message,omitempty"`              // 错误信息（如果有）
+	LastCheck           string `json:"last_check,omitempty"`           // 最后检查时间（后台检查）
+	LastSuccess         string `json:"last_success,omitempty"`         // 最后成功时间（后台检查）
+	ConsecutiveFailures int    `json:"consecutive_failures,omitempty"` // 连续失败次数（后台检查）
 }
 
 // HealthStatus 整体健康状态
 type HealthStatus struct {
-	Status    string                 `json:"status"`           // "ok" 或 "error"
+	Status    string                 `json:"status"`           // "ok"、"degraded" 或 "error"
 	Timestamp string                 `json:"timestamp"`        // ISO8601 时间戳
 	Checks    map[string]CheckResult `json:"checks,omitempty"` // 各组件的检查结果
 }
 
-// Ping 执行轻量级检查（用于 Liveness）
+// Check 执行完整检查（用于 Readiness）
 //
 // 初级工程师学习要点：
-// - Ping 只检查连接是否存活
-// - 用于 Kubernetes Liveness Probe
-// - 失败时 Kubernetes 会重启 Pod
-func (m *Manager) Ping(ctx context.Context) *HealthStatus {
+// - 使用读锁（RLock）允许多个 goroutine 同时读取
+// - 使用 Context 控制超时，避免检查时间过长
+// - 使用 WaitGroup 等待所有检查完成
+// - 启用后台检查的检查器直接返回缓存结果，探针请求不会打到数据库
+// - 任何一个关键组件失败，整体状态为 error（返回 503）
+// - 只有非关键组件失败（如缓存），整体状态为 degraded（仍返回 200，不摘除流量）
+func (m *Manager) Check(ctx context.Context) *HealthStatus {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
@@ -95,46 +191,43 @@ func (m *Manager) Ping(ctx context.Context) *HealthStatus {
 	checkCtx, cancel := context.WithTimeout(ctx, m.config.Timeout)
 	defer cancel()
 
-	status := &HealthStatus{
-		Status:    "ok",
-		Timestamp: time.Now().Format(time.RFC3339),
-		Checks:    make(map[string]CheckResult),
-	}
+	status := newHealthStatus()
 
 	// 如果没有注册任何检查器，直接返回 ok
 	if len(m.checkers) == 0 {
 		return status
 	}
 
-	// 并发执行所有 Ping 检查
+	// 并发执行所有检查
 	var wg sync.WaitGroup
-	var mu sync.Mutex
+	var mu sync.Mutex // 保护 status.Checks 的并发写入
 
-	for name, checker := range m.checkers {
+	for name, e := range m.checkers {
 		wg.Add(1)
-		go func(name string, checker HealthChecker) {
+		go func(name string, e *entry) {
 			defer wg.Done()
 
-			// 执行 Ping 检查
-			err := checker.Ping(checkCtx)
+			var result CheckResult
+			if e.background {
+				// 读取后台检查的缓存结果
+				result = e.result()
+			} else {
+				// 实时检查
+				result = CheckResult{Status: StatusOK, Critical: e.critical}
+				err := e.checker.Check(checkCtx)
+				if err != nil {
+					result.Status = StatusError
+					result.Message = err.Error()
+				}
+				e.record(err)
+			}
 
 			// 记录结果
 			mu.Lock()
 			defer mu.Unlock()
 
-			if err != nil {
-				status.Checks[name] = CheckResult{
-					Status:  "error",
-					Message: err.Error(),
-				}
-				status.Status = "error"
-			} else {
-				status.Checks[name] = CheckResult{
-					Status:  "ok",
-					Message: "",
-				}
-			}
-		}(name, checker)
+			status.add(name, result)
+		}(name, e)
 	}
 
 	wg.Wait()
@@ -142,88 +235,66 @@ func (m *Manager) Ping(ctx context.Context) *HealthStatus {
 	return status
 }
 
-// Check 执行完整检查（用于 Readiness）
+// Cached 返回最近一次的检查结果，不执行任何检查（用于监控指标等高频读取的场景）
 //
 // 初级工程师学习要点：
-// - 使用读锁（RLock）允许多个 goroutine 同时读取
-// - 使用 Context 控制超时，避免检查时间过长
-// - 使用 WaitGroup 等待所有检查完成
-func (m *Manager) Check(ctx context.Context) *HealthStatus {
+// - 启用后台检查的检查器返回缓存结果，其他检查器返回最近一次探针请求时的结果
+// - 还没有被检查过的检查器不包含在结果中
+// - Prometheus 每次拉取都会读取，不能像 Check 一样实时访问数据库
+func (m *Manager) Cached() *HealthStatus {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	// 创建带超时的 Context
-	checkCtx, cancel := context.WithTimeout(ctx, m.config.Timeout)
-	defer cancel()
+	status := newHealthStatus()
+	for name, e := range m.checkers {
+		if e.checked() {
+			status.add(name, e.result())
+		}
+	}
+	return status
+}
 
-	status := &HealthStatus{
-		Status:    "ok",
+// newHealthStatus 创建状态为 ok 的整体健康状态
+func newHealthStatus() *HealthStatus {
+	return &HealthStatus{
+		Status:    StatusOK,
 		Timestamp: time.Now().Format(time.RFC3339),
 		Checks:    make(map[string]CheckResult),
 	}
+}
 
-	// 如果没有注册任何检查器，直接返回 ok
-	if len(m.checkers) == 0 {
-		return status
-	}
-
-	// 并发执行所有检查
-	var wg sync.WaitGroup
-	var mu sync.Mutex // 保护 status.Checks 的并发写入
-
-	for name, checker := range m.checkers {
-		wg.Add(1)
-		go func(name string, checker HealthChecker) {
-			defer wg.Done()
-
-			// 执行检查
-			err := checker.Check(checkCtx)
-
-			// 记录结果
-			mu.Lock()
-			defer mu.Unlock()
-
-			if err != nil {
-				status.Checks[name] = CheckResult{
-					Status:  "error",
-					Message: err.Error(),
-				}
-				status.Status = "error" // 任何一个检查失败，整体状态为 error
-			} else {
-				status.Checks[name] = CheckResult{
-					Status:  "ok",
-					Message: "",
-				}
-			}
-		}(name, checker)
+// add 添加单个检查器的结果并更新整体状态
+func (s *HealthStatus) add(name string, result CheckResult) {
+	s.Checks[name] = result
+	if result.Status != StatusOK {
+		// 关键组件失败为 error；只有非关键组件失败为 degraded（error 优先）
+		if result.Critical {
+			s.Status = StatusError
+		} else if s.Status == StatusOK {
+			s.Status = StatusDegraded
+		}
 	}
-
-	wg.Wait()
-
-	return status
 }
 
 // LivenessHandler 存活检查 HTTP 处理函数
 //
+// 架构思路：
+// - 只反映进程本身是否在运行，不执行任何检查器，也不读取后台检查的结果
+// - 数据库、Redis 故障由就绪探针处理（摘除流量），不能让所有 Pod 因为依赖故障被重启
+//
 // 初级工程师学习要点：
-// - Liveness 检查使用 Ping 方法（轻量级检查）
-// - 只检查连接是否存活，不检查功能是否完整
-// - 如果失败，Kubernetes 会重启 Pod
+// - 如果失败，Kubernetes 会重启 Pod，所以只要进程能响应就返回 200
+// - 正在关闭时同样返回 200（状态为 shutting_down），避免优雅关闭过程中被重启
 func (m *Manager) LivenessHandler(c *gin.Context) {
-	status := m.Ping(c.Request.Context())
-
-	// 根据配置决定是否返回详细信息
-	if !m.config.Detailed {
-		// 简化模式：只返回整体状态
-		c.JSON(getStatusCode(status.Status), gin.H{
-			"status":    status.Status,
-			"timestamp": status.Timestamp,
-		})
-		return
+	status := StatusOK
+	if m.IsShuttingDown() {
+		status = "shutting_down"
 	}
 
-	// 详细模式：返回所有组件的状态
-	c.JSON(getStatusCode(status.Status), status)
+	c.JSON(200, gin.H{
+		"status":    status,
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
 }
 
 // ReadinessHandler 就绪检查 HTTP 处理函数
@@ -233,8 +304,46 @@ func (m *Manager) LivenessHandler(c *gin.Context) {
 // - 如果失败，Kubernetes 会将 Pod 从 Service 中移除（不再接收流量）
 // - 但不会重启 Pod
 func (m *Manager) ReadinessHandler(c *gin.Context) {
+	// 正在关闭时直接返回 503，不再执行依赖检查
+	if m.IsShuttingDown() {
+		c.JSON(503, gin.H{
+			"status":    "shutting_down",
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	m.writeStatus(c, m.Check(c.Request.Context()))
+}
+
+// StartupHandler 启动检查 HTTP 处理函数
+//
+// 初级工程师学习要点：
+// - Startup 探针用于启动较慢的服务（如依赖需要预热、从库需要追平数据）
+// - 启动探针通过之前，Kubernetes 不会执行存活和就绪探针，避免服务还没起来就被重启
+// - 所有关键依赖第一次检查通过后记为已启动，之后直接返回 200，不再重复检查
+func (m *Manager) StartupHandler(c *gin.Context) {
+	if m.IsStarted() {
+		c.JSON(200, gin.H{
+			"status":    StatusOK,
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
 	status := m.Check(c.Request.Context())
+	if status.Status != StatusError {
+		m.started.Store(true)
+	}
 
+	m.writeStatus(c, status)
+}
+
+// writeStatus 输出检查结果
+//
+// 初级工程师学习要点：
+// - health.detailed 为 false 时只返回整体状态，避免对外暴露内部组件信息
+func (m *Manager) writeStatus(c *gin.Context, status *HealthStatus) {
 	// 根据配置决定是否返回详细信息
 	if !m.config.Detailed {
 		// 简化模式：只返回整体状态
@@ -252,10 +361,10 @@ func (m *Manager) ReadinessHandler(c *gin.Context) {
 // getStatusCode 根据状态返回 HTTP 状态码
 //
 // 初级工程师学习要点：
-// - 健康返回 200，不健康返回 503（Service Unavailable）
-// - Kubernetes 根据状态码判断 Pod 是否就绪
+// - 健康和降级返回 200，不健康返回 503（Service Unavailable）
+// - Kubernetes 根据状态码判断 Pod 是否就绪，降级时仍保留在负载均衡中
 func getStatusCode(status string) int {
-	if status == "ok" {
+	if status == StatusOK || status == StatusDegraded {
 		return 200
 	}
 	return 503
@@ -14,19 +14,12 @@ import (
 // - 中间件是在请求处理前后执行的函数
 // - 这个中间件会记录每个 HTTP 请求的信息
 // - 替换 Gin 默认的 Logger 中间件
+// - TraceID 由 middleware.Trace 写入 Request.Context，这里通过 InfoContext 自动带上
 func GinLogger(logger *Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 记录请求开始时间
 		start := time.Now()
 
-		// 从 Header 中读取 TraceID
-		traceID := c.GetHeader("X-Trace-ID")
-		if traceID != "" {
-			// 将 TraceID 存入 Context
-			ctx := WithTraceID(c.Request.Context(), traceID)
-			c.Request = c.Request.WithContext(ctx)
-		}
-
 		// 处理请求
 		c.Next()
 
@@ -12,6 +12,7 @@ import (
 	"context"
 	"os"
 
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"gopkg.in/natefinch/lumberjack.v2"
@@ -34,6 +35,7 @@ const (
 // - 通过 Context 传递 TraceID，实现请求链路追踪
 type Logger struct {
 	zap    *zap.Logger
+	level  zap.AtomicLevel // 日志级别（可运行时修改）
 	config config.LoggerConfig
 }
 
@@ -72,7 +74,8 @@ func New(cfg config.LoggerConfig) (*Logger, error) {
 	}
 
 	// 3. 解析日志级别
-	level := parseLevel(cfg.Level)
+	// 使用 AtomicLevel，运行时可以通过 SetLevel 修改，无需重建 Logger
+	level := zap.NewAtomicLevelAt(parseLevel(cfg.Level))
 
 	// 4. 配置输出目标（支持多个目标）
 	var writers []zapcore.WriteSyncer
@@ -129,10 +132,20 @@ func New(cfg config.LoggerConfig) (*Logger, error) {
 
 	return &Logger{
 		zap:    zapLogger,
+		level:  level,
 		config: cfg,
 	}, nil
 }
 
+// SetLevel 修改日志级别（用于配置热更新）
+//
+// 初级工程师学习要点：
+// - 线上排查问题时可以临时调成 debug，排查完再调回 info，不需要重启服务
+// - 只影响之后写入的日志，并发调用是安全的
+func (l *Logger) SetLevel(level string) {
+	l.level.SetLevel(parseLevel(level))
+}
+
 // parseLevel 解析日志级别字符串
 //
 // 初级工程师学习要点：
@@ -167,17 +180,36 @@ func WithTraceID(ctx context.Context, traceID string) context.Context {
 }
 
 // GetTraceID 从 Context 中获取 TraceID
+//
+// 初级工程师学习要点：
+// - 优先使用链路追踪中间件写入的 TraceID
+// - 没有时（如后台任务）退化为当前 OpenTelemetry Span 的 TraceID
 func GetTraceID(ctx context.Context) string {
 	if traceID, ok := ctx.Value(traceIDKey).(string); ok {
 		return traceID
 	}
+	if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.HasTraceID() {
+		return spanCtx.TraceID().String()
+	}
+	return ""
+}
+
+// GetSpanID 从 Context 中获取当前 Span 的 SpanID
+//
+// 初级工程师学习要点：
+// - SpanID 由 OpenTelemetry 管理，存储在 Context 中的当前 Span 上
+// - 同一个请求内，数据库、Redis 等子操作的 SpanID 各不相同
+func GetSpanID(ctx context.Context) string {
+	if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.HasSpanID() {
+		return spanCtx.SpanID().String()
+	}
 	return ""
 }
 
 // withContext 从 Context 中提取字段并添加到日志
 //
 // 初级工程师学习要点：
-// - 这个方法会自动从 Context 中提取 TraceID
+// - 这个方法会自动从 Context 中提取 TraceID 和 SpanID
 // - 这样每条日志都会包含 TraceID，方便追踪请求
 func (l *Logger) withContext(ctx context.Context) *zap.Logger {
 	if ctx == nil {
@@ -191,6 +223,11 @@ func (l *Logger) withContext(ctx context.Context) *zap.Logger {
 		fields = append(fields, zap.String("trace_id", traceID))
 	}
 
+	// 添加 SpanID（如果存在）
+	if spanID := GetSpanID(ctx); spanID != "" {
+		fields = append(fields, zap.String("span_id", spanID))
+	}
+
 	if len(fields) > 0 {
 		return l.zap.With(fields...)
 	}
@@ -0,0 +1,174 @@
+// Package metrics 连接池与健康检查指标采集器
+package metrics
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/jingpc/awesome-be/internal/database"
+	"github.com/jingpc/awesome-be/internal/health"
+	"github.com/jingpc/awesome-be/internal/redis"
+)
+
+// dbCollector 数据库连接池指标采集器
+//
+// 初级工程师学习要点：
+// - 实现 prometheus.Collector 接口（Describe + Collect）
+// - Prometheus 拉取时调用 Collect，此时才读取 sql.DBStats，数据总是最新的
+type dbCollector struct {
+	mgr *database.Manager
+
+	maxOpen           *prometheus.Desc
+	open              *prometheus.Desc
+	inUse             *prometheus.Desc
+	idle              *prometheus.Desc
+	waitCount         *prometheus.Desc
+	waitDuration      *prometheus.Desc
+	maxIdleClosed     *prometheus.Desc
+	maxIdleTimeClosed *prometheus.Desc
+	maxLifetimeClosed *prometheus.Desc
+	replicaLag        *prometheus.Desc
+}
+
+// newDBCollector 创建数据库连接池指标采集器
+func newDBCollector(mgr *database.Manager) *dbCollector {
+	labels := []string{"db", "role", "instance"}
+	return &dbCollector{
+		mgr:               mgr,
+		maxOpen:           prometheus.NewDesc("db_pool_max_open_connections", "Maximum number of open connections to the database.", labels, nil),
+		open:              prometheus.NewDesc("db_pool_open_connections", "Number of established connections, both in use and idle.", labels, nil),
+		inUse:             prometheus.NewDesc("db_pool_in_use_connections", "Number of connections currently in use.", labels, nil),
+		idle:              prometheus.NewDesc("db_pool_idle_connections", "Number of idle connections.", labels, nil),
+		waitCount:         prometheus.NewDesc("db_pool_wait_count_total", "Total number of connections waited for.", labels, nil),
+		waitDuration:      prometheus.NewDesc("db_pool_wait_duration_seconds_total", "Total time blocked waiting for a new connection.", labels, nil),
+		maxIdleClosed:     prometheus.NewDesc("db_pool_max_idle_closed_total", "Total number of connections closed due to max_idle_conns.", labels, nil),
+		maxIdleTimeClosed: prometheus.NewDesc("db_pool_max_idle_time_closed_total", "Total number of connections closed due to conn_max_idle_time.", labels, nil),
+		maxLifetimeClosed: prometheus.NewDesc("db_pool_max_lifetime_closed_total", "Total number of connections closed due to conn_max_lifetime.", labels, nil),
+		replicaLag:        prometheus.NewDesc("db_replica_lag_seconds", "Last measured replication lag of the replica.", labels, nil),
+	}
+}
+
+// Describe 实现 prometheus.Collector 接口
+func (c *dbCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxOpen
+	ch <- c.open
+	ch <- c.inUse
+	ch <- c.idle
+	ch <- c.waitCount
+	ch <- c.waitDuration
+	ch <- c.maxIdleClosed
+	ch <- c.maxIdleTimeClosed
+	ch <- c.maxLifetimeClosed
+	ch <- c.replicaLag
+}
+
+// Collect 实现 prometheus.Collector 接口
+func (c *dbCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, db := range c.mgr.List() {
+		for _, inst := range db.Stats() {
+			labels := []string{db.Name(), inst.Role, inst.Instance}
+			s := inst.Stats
+
+			ch <- prometheus.MustNewConstMetric(c.maxOpen, prometheus.GaugeValue, float64(s.MaxOpenConnections), labels...)
+			ch <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, float64(s.OpenConnections), labels...)
+			ch <- prometheus.MustNewConstMetric(c.inUse, prometheus.GaugeValue, float64(s.InUse), labels...)
+			ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(s.Idle), labels...)
+			ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(s.WaitCount), labels...)
+			ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, s.WaitDuration.Seconds(), labels...)
+			ch <- prometheus.MustNewConstMetric(c.maxIdleClosed, prometheus.CounterValue, float64(s.MaxIdleClosed), labels...)
+			ch <- prometheus.MustNewConstMetric(c.maxIdleTimeClosed, prometheus.CounterValue, float64(s.MaxIdleTimeClosed), labels...)
+			ch <- prometheus.MustNewConstMetric(c.maxLifetimeClosed, prometheus.CounterValue, float64(s.MaxLifetimeClosed), labels...)
+			if inst.Role == "slave" {
+				ch <- prometheus.MustNewConstMetric(c.replicaLag, prometheus.GaugeValue, inst.Lag.Seconds(), labels...)
+			}
+		}
+	}
+}
+
+// redisCollector Redis 连接池指标采集器
+type redisCollector struct {
+	rdb *redis.Redis
+
+	hits       *prometheus.Desc
+	misses     *prometheus.Desc
+	timeouts   *prometheus.Desc
+	total      *prometheus.Desc
+	idle       *prometheus.Desc
+	staleConns *prometheus.Desc
+}
+
+// newRedisCollector 创建 Redis 连接池指标采集器
+func newRedisCollector(rdb *redis.Redis) *redisCollector {
+	labels := []string{"redis"}
+	return &redisCollector{
+		rdb:        rdb,
+		hits:       prometheus.NewDesc("redis_pool_hits_total", "Number of times a free connection was found in the pool.", labels, nil),
+		misses:     prometheus.NewDesc("redis_pool_misses_total", "Number of times a free connection was not found in the pool.", labels, nil),
+		timeouts:   prometheus.NewDesc("redis_pool_timeouts_total", "Number of times a wait timeout occurred.", labels, nil),
+		total:      prometheus.NewDesc("redis_pool_total_connections", "Number of total connections in the pool.", labels, nil),
+		idle:       prometheus.NewDesc("redis_pool_idle_connections", "Number of idle connections in the pool.", labels, nil),
+		staleConns: prometheus.NewDesc("redis_pool_stale_connections_total", "Number of stale connections removed from the pool.", labels, nil),
+	}
+}
+
+// Describe 实现 prometheus.Collector 接口
+func (c *redisCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.timeouts
+	ch <- c.total
+	ch <- c.idle
+	ch <- c.staleConns
+}
+
+// Collect 实现 prometheus.Collector 接口
+func (c *redisCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.rdb.PoolStats()
+	name := c.rdb.Name()
+
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(s.Hits), name)
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(s.Misses), name)
+	ch <- prometheus.MustNewConstMetric(c.timeouts, prometheus.CounterValue, float64(s.Timeouts), name)
+	ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(s.TotalConns), name)
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(s.IdleConns), name)
+	ch <- prometheus.MustNewConstMetric(c.staleConns, prometheus.CounterValue, float64(s.StaleConns), name)
+}
+
+// healthCollector 健康检查结果指标采集器
+//
+// 初级工程师学习要点：
+// - 每个检查器输出一条 health_check_status，1 表示正常，0 表示异常
+// - critical 标签区分关键组件，告警时可以只对关键组件发送紧急通知
+// - 可以直接基于该指标配置告警，例如 health_check_status == 0 持续 1 分钟
+// - 只读取最近一次的检查结果（Manager.Cached），拉取指标不会触发对数据库、Redis 的检查
+type healthCollector struct {
+	mgr    *health.Manager
+	status *prometheus.Desc
+}
+
+// newHealthCollector 创建健康检查结果指标采集器
+func newHealthCollector(mgr *health.Manager) *healthCollector {
+	return &healthCollector{
+		mgr:    mgr,
+		status: prometheus.NewDesc("health_check_status", "Result of the readiness check per checker (1 = ok, 0 = error).", []string{"checker", "critical"}, nil),
+	}
+}
+
+// Describe 实现 prometheus.Collector 接口
+func (c *healthCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.status
+}
+
+// Collect 实现 prometheus.Collector 接口
+func (c *healthCollector) Collect(ch chan<- prometheus.Metric) {
+	status := c.mgr.Cached()
+
+	for name, result := range status.Checks {
+		value := 0.0
+		if result.Status == health.StatusOK {
+			value = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.status, prometheus.GaugeValue, value, name, strconv.FormatBool(result.Critical))
+	}
+}
@@ -0,0 +1,120 @@
+// Package metrics 提供 Prometheus 监控指标
+//
+// 监控指标模块基于 Prometheus client_golang 实现，采集 HTTP 请求、数据库连接池、
+// Redis 连接池、健康检查结果和 Go 运行时指标，通过 /metrics 端点暴露给 Prometheus 拉取。
+//
+// 初级工程师学习要点：
+// - Counter 只增不减（请求总数），Gauge 可增可减（连接数），Histogram 统计分布（延迟）
+// - 标签（label）用于区分维度，但标签值的组合数不能无限增长（高基数问题）
+// - 使用独立的 Registry 而不是全局 DefaultRegisterer，避免第三方库的指标混入
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/jingpc/awesome-be/internal/database"
+	"github.com/jingpc/awesome-be/internal/health"
+	"github.com/jingpc/awesome-be/internal/redis"
+)
+
+// unmatchedRoute 未匹配任何路由（404）时使用的 route 标签值
+const unmatchedRoute = "unmatched"
+
+// Metrics 监控指标
+//
+// 初级工程师学习要点：
+// - HTTP 指标在请求处理时由中间件实时记录
+// - 连接池和健康检查指标在 Prometheus 拉取时才读取（Collector 模式），不需要后台定时任务
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight prometheus.Gauge
+}
+
+// New 创建监控指标并注册 HTTP 和 Go 运行时指标
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Total number of HTTP requests.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency in seconds.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "Number of HTTP requests currently being served.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		m.requests,
+		m.duration,
+		m.inFlight,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return m
+}
+
+// RegisterDatabases 注册数据库连接池指标（主库和所有从库）
+func (m *Metrics) RegisterDatabases(mgr *database.Manager) {
+	m.registry.MustRegister(newDBCollector(mgr))
+}
+
+// RegisterRedis 注册 Redis 连接池指标
+func (m *Metrics) RegisterRedis(rdb *redis.Redis) {
+	m.registry.MustRegister(newRedisCollector(rdb))
+}
+
+// RegisterHealth 注册健康检查结果指标
+func (m *Metrics) RegisterHealth(healthMgr *health.Manager) {
+	m.registry.MustRegister(newHealthCollector(healthMgr))
+}
+
+// Handler 返回 Prometheus 拉取指标的 HTTP 处理器
+//
+// 使用示例：
+//
+//	engine.GET("/metrics", gin.WrapH(m.Handler()))
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Middleware 返回记录 HTTP 请求指标的中间件
+//
+// 初级工程师学习要点：
+// - route 标签使用路由模板（如 /api/v1/users/:id），而不是实际路径，避免高基数
+// - 未匹配任何路由的请求统一记为 unmatched，防止扫描器用随机路径撑爆指标
+// - 应放在 Recovery 之前，才能记录到 panic 后的 500 状态码
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		m.inFlight.Inc()
+		defer m.inFlight.Dec()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		status := strconv.Itoa(c.Writer.Status())
+
+		m.requests.WithLabelValues(c.Request.Method, route, status).Inc()
+		m.duration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
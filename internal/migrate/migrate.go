@@ -0,0 +1,475 @@
+// Package migrate 迁移的执行与版本记录
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jingpc/awesome-be/internal/config"
+	"github.com/jingpc/awesome-be/internal/database"
+)
+
+// 迁移的默认参数（未配置 migrations 时使用）
+const (
+	defaultTable       = "schema_migrations"
+	defaultLockTimeout = 5 * time.Minute
+	defaultDirRoot     = "migrations"
+)
+
+// Dir 返回数据库的迁移文件目录（未配置时为 migrations/数据库名称）
+func Dir(cfg config.DatabaseConfig) string {
+	if cfg.Migrations.Dir != "" {
+		return cfg.Migrations.Dir
+	}
+	return filepath.Join(defaultDirRoot, cfg.Name)
+}
+
+// Status 单个迁移的状态
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool      // 是否已执行
+	AppliedAt time.Time // 执行时间
+	Dirty     bool      // 执行到一半失败（MySQL 的 DDL 无法回滚），需要手动处理
+	Missing   bool      // 已执行但迁移文件不存在（如切换到了旧分支）
+	Modified  bool      // 执行后迁移文件被修改（校验和不一致）
+}
+
+// record 迁移表中的一行
+type record struct {
+	version   int64
+	name      string
+	checksum  string
+	dirty     bool
+	appliedAt time.Time
+}
+
+// Migrator 迁移执行器（一个数据库一个）
+//
+// 架构思路：
+// - 迁移只在主库执行，使用单独的连接，迁移期间一直持有数据库锁
+// - 加锁后再读取迁移表，等待锁的实例拿到锁时会发现迁移已经执行完，直接返回
+// - PostgreSQL、SQLite 支持事务中执行 DDL：SQL 和迁移记录在同一个事务中提交，失败时全部回滚
+// - MySQL 的 DDL 会隐式提交事务：先记录为 dirty，执行成功后再清除，失败时保留 dirty 标记等待人工处理
+//
+// 初级工程师学习要点：
+// - 多个实例同时启动时靠数据库锁保证只有一个执行迁移：MySQL 用 GET_LOCK，PostgreSQL 用 pg_advisory_lock
+// - SQLite 是单机文件数据库，依靠写事务互斥和迁移表主键防止重复执行
+// - 执行前检查已执行迁移的校验和，迁移文件被修改过、存在 dirty 迁移时拒绝执行
+type Migrator struct {
+	db          *sql.DB
+	dbType      string
+	table       string
+	lockTimeout time.Duration
+	migrations  []Migration
+}
+
+// New 创建迁移执行器
+//
+// 使用示例：
+//
+//	migrations, err := migrate.Load(os.DirFS(migrate.Dir(cfg)))
+//	m, err := migrate.New(db, cfg.Migrations, migrations)
+//	applied, err := m.Up(ctx)
+func New(db *database.Database, cfg config.MigrationConfig, migrations []Migration) (*Migrator, error) {
+	sqlDB, err := db.Master(context.Background()).DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sql.DB: %w", err)
+	}
+
+	m := &Migrator{
+		db:          sqlDB,
+		dbType:      db.Type(),
+		table:       cfg.Table,
+		lockTimeout: cfg.LockTimeout,
+		migrations:  migrations,
+	}
+	if m.table == "" {
+		m.table = defaultTable
+	}
+	if m.lockTimeout <= 0 {
+		m.lockTimeout = defaultLockTimeout
+	}
+	return m, nil
+}
+
+// Open 读取数据库的迁移文件并创建迁移执行器（迁移目录不存在时没有迁移）
+func Open(db *database.Database, cfg config.DatabaseConfig) (*Migrator, error) {
+	var migrations []Migration
+	dir := Dir(cfg)
+	if _, err := os.Stat(dir); err == nil {
+		if migrations, err = Load(os.DirFS(dir)); err != nil {
+			return nil, fmt.Errorf("failed to load migrations from %s: %w", dir, err)
+		}
+	}
+	return New(db, cfg.Migrations, migrations)
+}
+
+// Up 执行所有未执行的迁移（按版本号从小到大），返回本次执行的迁移
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	applied, _, err := m.Goto(ctx, -1)
+	return applied, err
+}
+
+// Down 回滚最近执行的 n 个迁移（按版本号从大到小），返回本次回滚的迁移
+func (m *Migrator) Down(ctx context.Context, n int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn, records map[int64]record) error {
+		versions := appliedVersions(records)
+		for i := len(versions) - 1; i >= 0 && len(done) < n; i-- {
+			migration, err := m.rollback(ctx, conn, records[versions[i]])
+			if err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Goto 迁移到指定版本：回滚版本号大于 version 的已执行迁移，执行版本号不超过 version 的未执行迁移
+//
+// 初级工程师学习要点：
+// - version 为 0 表示回滚全部迁移，-1 表示执行全部迁移（即 Up）
+// - 先从大到小回滚，再从小到大执行，返回本次执行和回滚的迁移
+func (m *Migrator) Goto(ctx context.Context, version int64) (applied, rolledBack []Migration, err error) {
+	if version > 0 && m.find(version) == nil {
+		return nil, nil, fmt.Errorf("migration version %d not found", version)
+	}
+
+	err = m.withLock(ctx, func(conn *sql.Conn, records map[int64]record) error {
+		if version >= 0 {
+			versions := appliedVersions(records)
+			for i := len(versions) - 1; i >= 0 && versions[i] > version; i-- {
+				migration, err := m.rollback(ctx, conn, records[versions[i]])
+				if err != nil {
+					return err
+				}
+				rolledBack = append(rolledBack, migration)
+			}
+		}
+
+		// 版本号小于已执行迁移的未执行迁移（如其他分支合并进来的迁移）同样会执行
+		for _, migration := range m.migrations {
+			if _, ok := records[migration.Version]; ok || (version >= 0 && migration.Version > version) {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration); err != nil {
+				return err
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, rolledBack, err
+}
+
+// Status 返回所有迁移的状态（迁移文件和迁移表合并，按版本号排序）
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return nil, err
+	}
+	records, err := m.records(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		s := Status{Version: migration.Version, Name: migration.Name}
+		if r, ok := records[migration.Version]; ok {
+			s.Applied, s.AppliedAt, s.Dirty = true, r.appliedAt, r.dirty
+			s.Modified = r.checksum != migration.Checksum()
+		}
+		statuses = append(statuses, s)
+	}
+	for version, r := range records {
+		if m.find(version) == nil {
+			statuses = append(statuses, Status{Version: version, Name: r.name, Applied: true, AppliedAt: r.appliedAt, Dirty: r.dirty, Missing: true})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+
+	return statuses, nil
+}
+
+// withLock 在持有迁移锁的连接上执行 fn（fn 收到加锁后读取的迁移记录）
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn, records map[int64]record) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := m.lock(ctx, conn); err != nil {
+		return err
+	}
+	defer m.unlock(conn)
+
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	records, err := m.records(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if err := m.verify(records); err != nil {
+		return err
+	}
+
+	return fn(conn, records)
+}
+
+// lock 获取迁移锁（按 数据库名.迁移表 区分，同一台服务器上的不同数据库互不影响）
+func (m *Migrator) lock(ctx context.Context, conn *sql.Conn) error {
+	lockCtx, cancel := context.WithTimeout(ctx, m.lockTimeout)
+	defer cancel()
+
+	var err error
+	acquired := true
+	switch m.dbType {
+	case "mysql":
+		// GET_LOCK 超时返回 0，出错返回 NULL
+		var result sql.NullInt64
+		err = conn.QueryRowContext(lockCtx, "SELECT GET_LOCK(CONCAT(DATABASE(), '.', ?), ?)",
+			m.table, int(m.lockTimeout.Seconds())).Scan(&result)
+		acquired = result.Int64 == 1
+
+	case "postgres":
+		// 会话级锁，一直等待，超时由 ctx 取消
+		_, err = conn.ExecContext(lockCtx, "SELECT pg_advisory_lock(hashtext(current_database() || '.' || $1))", m.table)
+	}
+
+	if (err == nil && !acquired) || lockCtx.Err() != nil {
+		return fmt.Errorf("failed to acquire migration lock within %s (another instance is migrating)", m.lockTimeout)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	return nil
+}
+
+// unlock 释放迁移锁
+//
+// 锁属于连接（会话），conn.Close 只是把连接放回连接池，锁并不会释放；
+// 释放失败时丢弃这个连接，数据库在连接断开时释放锁
+func (m *Migrator) unlock(conn *sql.Conn) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var err error
+	switch m.dbType {
+	case "mysql":
+		_, err = conn.ExecContext(ctx, "SELECT RELEASE_LOCK(CONCAT(DATABASE(), '.', ?))", m.table)
+	case "postgres":
+		_, err = conn.ExecContext(ctx, "SELECT pg_advisory_unlock(hashtext(current_database() || '.' || $1))", m.table)
+	}
+
+	if err != nil {
+		_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+	}
+}
+
+// ensureTable 创建迁移表（已存在时跳过）
+//
+// 初级工程师学习要点：
+// - applied_at 保存为 RFC 3339 格式的字符串，不依赖各数据库驱动对时间类型的处理（如 MySQL 的 parseTime）
+func (m *Migrator) ensureTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+m.table+` (
+		version BIGINT NOT NULL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		checksum VARCHAR(64) NOT NULL,
+		dirty BOOLEAN NOT NULL DEFAULT FALSE,
+		applied_at VARCHAR(32) NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create migration table %s: %w", m.table, err)
+	}
+	return nil
+}
+
+// records 读取迁移表
+func (m *Migrator) records(ctx context.Context, conn *sql.Conn) (map[int64]record, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, name, checksum, dirty, applied_at FROM "+m.table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migration table %s: %w", m.table, err)
+	}
+	defer rows.Close()
+
+	records := make(map[int64]record)
+	for rows.Next() {
+		var r record
+		var appliedAt string
+		if err := rows.Scan(&r.version, &r.name, &r.checksum, &r.dirty, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to read migration table %s: %w", m.table, err)
+		}
+		r.appliedAt, _ = time.Parse(time.RFC3339, appliedAt)
+		records[r.version] = r
+	}
+	return records, rows.Err()
+}
+
+// verify 检查已执行的迁移：不能有 dirty 迁移，迁移文件不能被修改
+func (m *Migrator) verify(records map[int64]record) error {
+	for _, version := range appliedVersions(records) {
+		r := records[version]
+		if r.dirty {
+			return fmt.Errorf("migration %d_%s is dirty (failed halfway), fix the schema manually and then delete its row from %s (or set dirty to false if it was completed)",
+				r.version, r.name, m.table)
+		}
+		if migration := m.find(version); migration != nil && migration.Checksum() != r.checksum {
+			return fmt.Errorf("migration %d_%s was modified after being applied (checksum mismatch), create a new migration instead", r.version, r.name)
+		}
+	}
+	return nil
+}
+
+// apply 执行单个迁移并记录
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	insert := m.bind("INSERT INTO " + m.table + " (version, name, checksum, dirty, applied_at) VALUES (?, ?, ?, ?, ?)")
+	appliedAt := time.Now().UTC().Format(time.RFC3339)
+
+	err := m.run(ctx, conn, migration.Up,
+		func(exec execer, dirty bool) error {
+			_, err := exec.ExecContext(ctx, insert, migration.Version, migration.Name, migration.Checksum(), dirty, appliedAt)
+			return err
+		},
+		func(exec execer) error {
+			_, err := exec.ExecContext(ctx, m.bind("UPDATE "+m.table+" SET dirty = ? WHERE version = ?"), false, migration.Version)
+			return err
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+// rollback 回滚单个迁移并删除记录
+func (m *Migrator) rollback(ctx context.Context, conn *sql.Conn, r record) (Migration, error) {
+	migration := m.find(r.version)
+	switch {
+	case migration == nil:
+		return Migration{}, fmt.Errorf("migration %d_%s cannot be rolled back: migration file not found", r.version, r.name)
+	case strings.TrimSpace(migration.Down) == "":
+		return Migration{}, fmt.Errorf("migration %d_%s cannot be rolled back: no down migration", r.version, r.name)
+	}
+
+	err := m.run(ctx, conn, migration.Down,
+		func(exec execer, dirty bool) error {
+			if !dirty {
+				return nil
+			}
+			_, err := exec.ExecContext(ctx, m.bind("UPDATE "+m.table+" SET dirty = ? WHERE version = ?"), true, r.version)
+			return err
+		},
+		func(exec execer) error {
+			_, err := exec.ExecContext(ctx, m.bind("DELETE FROM "+m.table+" WHERE version = ?"), r.version)
+			return err
+		},
+	)
+	if err != nil {
+		return Migration{}, fmt.Errorf("rollback of migration %d_%s failed: %w", r.version, r.name, err)
+	}
+	return *migration, nil
+}
+
+// execer 连接或事务
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// run 执行迁移 SQL，before、after 分别在 SQL 之前、之后更新迁移表
+//
+// 支持事务 DDL 时 before（dirty 为 false）、SQL、after 在同一个事务中执行；
+// MySQL 不支持，before（dirty 为 true）先单独提交，SQL 执行成功后 after 清除 dirty 标记
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, script string, before func(exec execer, dirty bool) error, after func(exec execer) error) error {
+	if m.dbType == "mysql" {
+		if err := before(conn, true); err != nil {
+			return err
+		}
+		for _, statement := range splitStatements(script) {
+			if _, err := conn.ExecContext(ctx, statement); err != nil {
+				return err
+			}
+		}
+		return after(conn)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := before(tx, false); err != nil {
+		return err
+	}
+	if strings.TrimSpace(script) != "" {
+		if _, err := tx.ExecContext(ctx, script); err != nil {
+			return err
+		}
+	}
+	if err := after(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// bind 将 ? 占位符转换为数据库使用的格式（PostgreSQL 为 $1、$2）
+func (m *Migrator) bind(query string) string {
+	if m.dbType != "postgres" {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// find 查找迁移文件
+func (m *Migrator) find(version int64) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+// appliedVersions 返回已执行迁移的版本号（从小到大）
+func appliedVersions(records map[int64]record) []int64 {
+	versions := make([]int64, 0, len(records))
+	for version := range records {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i] < versions[j]
+	})
+	return versions
+}
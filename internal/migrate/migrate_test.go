@@ -0,0 +1,252 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jingpc/awesome-be/internal/config"
+	"github.com/jingpc/awesome-be/internal/database"
+	"github.com/jingpc/awesome-be/internal/database/databasetest"
+)
+
+// testMigrations 测试用的迁移
+var testMigrations = []Migration{
+	{Version: 1, Name: "create_users", Up: "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);", Down: "DROP TABLE users;"},
+	{Version: 2, Name: "add_email", Up: "ALTER TABLE users ADD COLUMN email TEXT;", Down: "ALTER TABLE users DROP COLUMN email;"},
+	{Version: 3, Name: "create_orders", Up: "CREATE TABLE orders (id INTEGER PRIMARY KEY);", Down: "DROP TABLE orders;"},
+}
+
+// newTestMigrator 创建迁移执行器
+func newTestMigrator(t *testing.T, db *database.Database, migrations []Migration) *Migrator {
+	t.Helper()
+
+	m, err := New(db, config.MigrationConfig{}, migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// sqlDB 返回主库的 *sql.DB（用于直接修改迁移表）
+func sqlDB(t *testing.T, db *database.Database) *sql.DB {
+	t.Helper()
+
+	sqlDB, err := db.Master(context.Background()).DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sqlDB
+}
+
+// versions 返回迁移的版本号
+func versions(migrations []Migration) []int64 {
+	result := make([]int64, len(migrations))
+	for i, m := range migrations {
+		result[i] = m.Version
+	}
+	return result
+}
+
+// tableExists 检查表是否存在
+func tableExists(t *testing.T, db *sql.DB, table string) bool {
+	t.Helper()
+
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n > 0
+}
+
+func TestUpGotoDown(t *testing.T) {
+	db := databasetest.NewSQLite(t)
+	m := newTestMigrator(t, db, testMigrations)
+	ctx := context.Background()
+
+	tests := []struct {
+		name           string
+		run            func() (applied, rolledBack []Migration, err error)
+		wantApplied    []int64
+		wantRolledBack []int64
+	}{
+		{
+			name:        "goto 2",
+			run:         func() ([]Migration, []Migration, error) { return m.Goto(ctx, 2) },
+			wantApplied: []int64{1, 2},
+		},
+		{
+			name: "up",
+			run: func() ([]Migration, []Migration, error) {
+				applied, err := m.Up(ctx)
+				return applied, nil, err
+			},
+			wantApplied: []int64{3},
+		},
+		{
+			name: "up again does nothing",
+			run: func() ([]Migration, []Migration, error) {
+				applied, err := m.Up(ctx)
+				return applied, nil, err
+			},
+		},
+		{
+			name:           "goto 1",
+			run:            func() ([]Migration, []Migration, error) { return m.Goto(ctx, 1) },
+			wantRolledBack: []int64{3, 2},
+		},
+		{
+			name: "down",
+			run: func() ([]Migration, []Migration, error) {
+				rolledBack, err := m.Down(ctx, 5)
+				return nil, rolledBack, err
+			},
+			wantRolledBack: []int64{1},
+		},
+	}
+	for _, tt := range tests {
+		applied, rolledBack, err := tt.run()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := versions(applied); !slices.Equal(got, tt.wantApplied) {
+			t.Errorf("%s: applied %v, want %v", tt.name, got, tt.wantApplied)
+		}
+		if got := versions(rolledBack); !slices.Equal(got, tt.wantRolledBack) {
+			t.Errorf("%s: rolled back %v, want %v", tt.name, got, tt.wantRolledBack)
+		}
+	}
+
+	if tableExists(t, sqlDB(t, db), "users") {
+		t.Error("users table still exists after rolling back all migrations")
+	}
+}
+
+func TestFailedMigrationRollsBack(t *testing.T) {
+	db := databasetest.NewSQLite(t)
+	migrations := []Migration{
+		testMigrations[0],
+		{Version: 2, Name: "broken", Up: "CREATE TABLE orders (id INTEGER PRIMARY KEY);\nINSERT INTO missing VALUES (1);"},
+	}
+	m := newTestMigrator(t, db, migrations)
+
+	applied, err := m.Up(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "migration 2_broken failed") {
+		t.Fatalf("Up: got error %v, want migration 2_broken failed", err)
+	}
+	if got := versions(applied); !slices.Equal(got, []int64{1}) {
+		t.Errorf("applied %v, want [1]", got)
+	}
+
+	// SQLite 支持事务 DDL：失败的迁移整体回滚，不留下 dirty 记录，可以修复后重新执行
+	if tableExists(t, sqlDB(t, db), "orders") {
+		t.Error("orders table exists after the failed migration was rolled back")
+	}
+	statuses, err := m.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := statuses[1]; s.Applied || s.Dirty {
+		t.Errorf("status of failed migration: %+v", s)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name       string
+		modify     func(t *testing.T, db *sql.DB, migrations []Migration) []Migration
+		wantErr    string
+		wantStatus func(s Status) bool
+	}{
+		{
+			name: "checksum mismatch",
+			modify: func(t *testing.T, db *sql.DB, migrations []Migration) []Migration {
+				migrations[0].Up += "\nCREATE INDEX idx_users_name ON users (name);"
+				return migrations
+			},
+			wantErr:    "migration 1_create_users was modified after being applied (checksum mismatch)",
+			wantStatus: func(s Status) bool { return s.Modified },
+		},
+		{
+			name: "dirty migration",
+			modify: func(t *testing.T, db *sql.DB, migrations []Migration) []Migration {
+				if _, err := db.Exec("UPDATE schema_migrations SET dirty = ? WHERE version = 1", true); err != nil {
+					t.Fatal(err)
+				}
+				return migrations
+			},
+			wantErr:    "migration 1_create_users is dirty",
+			wantStatus: func(s Status) bool { return s.Dirty },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := databasetest.NewSQLite(t)
+			ctx := context.Background()
+			if _, err := newTestMigrator(t, db, testMigrations[:1]).Up(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			migrations := tt.modify(t, sqlDB(t, db), append([]Migration(nil), testMigrations...))
+			m := newTestMigrator(t, db, migrations)
+
+			// 发现问题时拒绝执行任何迁移（包括回滚）
+			applied, err := m.Up(ctx)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Up: got error %v, want %q", err, tt.wantErr)
+			}
+			if len(applied) > 0 {
+				t.Errorf("Up applied %v despite the error", versions(applied))
+			}
+			if _, err := m.Down(ctx, 1); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Down: got error %v, want %q", err, tt.wantErr)
+			}
+
+			// Status 不拒绝，用于排查问题
+			statuses, err := m.Status(ctx)
+			if err != nil {
+				t.Fatalf("Status: %v", err)
+			}
+			if !tt.wantStatus(statuses[0]) {
+				t.Errorf("status %+v", statuses[0])
+			}
+		})
+	}
+}
+
+func TestStatusMissingMigration(t *testing.T) {
+	db := databasetest.NewSQLite(t)
+	ctx := context.Background()
+	if _, err := newTestMigrator(t, db, testMigrations[:2]).Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// 切换到没有迁移 2 的分支
+	m := newTestMigrator(t, db, []Migration{testMigrations[0], testMigrations[2]})
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Status{
+		{Version: 1, Name: "create_users", Applied: true},
+		{Version: 2, Name: "add_email", Applied: true, Missing: true},
+		{Version: 3, Name: "create_orders"},
+	}
+	if len(statuses) != len(want) {
+		t.Fatalf("got %d statuses, want %d", len(statuses), len(want))
+	}
+	for i, s := range statuses {
+		s.AppliedAt = want[i].AppliedAt
+		if s != want[i] {
+			t.Errorf("status[%d] = %+v, want %+v", i, s, want[i])
+		}
+	}
+
+	// 迁移文件不存在时无法回滚
+	if _, err := m.Down(ctx, 1); err == nil || !strings.Contains(err.Error(), "migration file not found") {
+		t.Errorf("Down: got error %v, want migration file not found", err)
+	}
+}
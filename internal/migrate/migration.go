@@ -0,0 +1,224 @@
+// Package migrate 提供数据库迁移功能
+//
+// 数据库结构的变更（建表、加字段、加索引）写成带版本号的 SQL 文件，
+// 和代码一起提交、评审、发布，每个环境按相同的顺序执行，数据库结构始终与代码一致。
+//
+// 初级工程师学习要点：
+// - 每个迁移由 up（执行变更）和 down（撤销变更）两个 SQL 文件组成
+// - 已执行的迁移记录在迁移表中（版本号、校验和），不会重复执行
+// - 已经执行过的迁移文件不能再修改（校验和不一致时报错），需要调整时新建一个迁移
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migration 单个迁移
+type Migration struct {
+	Version int64  // 版本号（create 生成的是 UTC 时间，如 20250101120000）
+	Name    string // 名称，如 create_users
+	Up      string // 执行变更的 SQL
+	Down    string // 撤销变更的 SQL（为空表示不能回滚）
+}
+
+// Checksum 返回 up SQL 的校验和（SHA-256），用于发现执行后被修改的迁移文件
+func (m Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(m.Up))
+	return hex.EncodeToString(sum[:])
+}
+
+// fileNamePattern 迁移文件名：版本号_名称.up.sql 或 版本号_名称.down.sql
+var fileNamePattern = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Load 读取目录中的迁移文件（按版本号排序）
+//
+// 初级工程师学习要点：
+// - 参数是 fs.FS，既可以读磁盘目录（os.DirFS），也可以读 embed.FS（迁移文件打包进二进制）
+// - .sql 文件名不符合规则、版本号重复、缺少 up 文件时返回错误，避免迁移被悄悄跳过
+// - 可以没有 down 文件（无法撤销的变更，如删除数据），回滚到该迁移时报错
+//
+// 使用示例：
+//
+//	migrations, err := migrate.Load(os.DirFS("migrations/main"))
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	hasUp := make(map[int64]bool)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+
+		m := fileNamePattern.FindStringSubmatch(entry.Name())
+		if m == nil {
+			return nil, fmt.Errorf("invalid migration file name %s (expected <version>_<name>.up.sql or .down.sql)", entry.Name())
+		}
+		version, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration := byVersion[version]
+		if migration == nil {
+			migration = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != m[2] {
+			return nil, fmt.Errorf("migration version %d is used by both %s and %s", version, migration.Name, m[2])
+		}
+
+		if m[3] == "up" {
+			migration.Up = string(data)
+			hasUp[version] = true
+		} else {
+			migration.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for version, migration := range byVersion {
+		if !hasUp[version] {
+			return nil, fmt.Errorf("migration %d_%s has no up file", version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Create 在目录中创建一对新的迁移文件，返回两个文件的路径
+//
+// 初级工程师学习要点：
+// - 版本号使用当前 UTC 时间（精确到秒），多人同时开发时不容易冲突
+// - 名称转换为小写加下划线，如 "Add user email" -> add_user_email
+func Create(dir, name string, now time.Time) (upPath, downPath string, err error) {
+	name = strings.Trim(nonWordPattern.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return "", "", fmt.Errorf("migration name is required")
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", "", err
+	}
+
+	base := now.UTC().Format("20060102150405") + "_" + name
+	upPath = filepath.Join(dir, base+".up.sql")
+	downPath = filepath.Join(dir, base+".down.sql")
+
+	files := map[string]string{
+		upPath:   "-- " + name + "：执行变更\n",
+		downPath: "-- " + name + "：撤销 up 中的变更\n",
+	}
+	for _, path := range []string{upPath, downPath} {
+		// O_EXCL：文件已存在时报错，不覆盖已有的迁移
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return "", "", err
+		}
+		_, err = f.WriteString(files[path])
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	return upPath, downPath, nil
+}
+
+// nonWordPattern 迁移名称中需要替换为下划线的字符
+var nonWordPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// splitStatements 将 SQL 拆分为单条语句（MySQL 驱动默认不支持一次执行多条语句）
+//
+// 初级工程师学习要点：
+// - 按分号拆分，跳过字符串（'...'、"..."、`...`）和注释（--、#、/* */）中的分号
+// - 只包含注释和空白的语句会被丢弃
+// - 不支持 DELIMITER（存储过程），这类迁移需要在 params 中设置 multiStatements 后写成一条语句
+func splitStatements(sql string) []string {
+	var statements []string
+	var b strings.Builder
+	hasCode := false
+
+	flush := func() {
+		if hasCode {
+			statements = append(statements, strings.TrimSpace(b.String()))
+		}
+		b.Reset()
+		hasCode = false
+	}
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			// 字符串或标识符，直到相同的引号（反斜杠转义下一个字符）
+			end := i + 1
+			for end < len(sql) && sql[end] != c {
+				if sql[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end = min(end+1, len(sql))
+			b.WriteString(sql[i:end])
+			hasCode = true
+			i = end - 1
+
+		case c == '#' || (c == '-' && strings.HasPrefix(sql[i:], "--")):
+			// 单行注释
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql) - i
+			}
+			b.WriteString(sql[i : i+end])
+			i += end - 1
+
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			// 多行注释
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				end = len(sql) - i - 2
+			} else {
+				end += 2
+			}
+			b.WriteString(sql[i : i+2+end])
+			i += 2 + end - 1
+
+		case c == ';':
+			flush()
+
+		default:
+			b.WriteByte(c)
+			if c != ' ' && c != '\t' && c != '\n' && c != '\r' {
+				hasCode = true
+			}
+		}
+	}
+	flush()
+
+	return statements
+}
@@ -0,0 +1,143 @@
+package migrate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []string
+	}{
+		{
+			name: "multiple statements",
+			sql:  "CREATE TABLE a (id INT);\nCREATE INDEX idx_a ON a (id);\n",
+			want: []string{"CREATE TABLE a (id INT)", "CREATE INDEX idx_a ON a (id)"},
+		},
+		{
+			name: "last statement without semicolon",
+			sql:  "SELECT 1;\nSELECT 2",
+			want: []string{"SELECT 1", "SELECT 2"},
+		},
+		{
+			name: "semicolons in strings and identifiers",
+			sql:  `INSERT INTO a VALUES ('a;b', "c;d");` + " SELECT `e;f` FROM a;",
+			want: []string{`INSERT INTO a VALUES ('a;b', "c;d")`, "SELECT `e;f` FROM a"},
+		},
+		{
+			name: "escaped quotes",
+			sql:  `SELECT 'it\'s;'; SELECT 'it''s;';`,
+			want: []string{`SELECT 'it\'s;'`, `SELECT 'it''s;'`},
+		},
+		{
+			name: "semicolons in comments",
+			sql:  "-- drop; later\nCREATE TABLE a (id INT); # note;\n/* multi;\nline; */ SELECT 1;",
+			want: []string{"-- drop; later\nCREATE TABLE a (id INT)", "# note;\n/* multi;\nline; */ SELECT 1"},
+		},
+		{
+			name: "comment only statements are dropped",
+			sql:  "CREATE TABLE a (id INT);\n-- trailing comment\n;;\n/* done */\n",
+			want: []string{"CREATE TABLE a (id INT)"},
+		},
+		{
+			name: "empty",
+			sql:  "  \n\t",
+			want: nil,
+		},
+		{
+			name: "unterminated string",
+			sql:  "SELECT 'a;b",
+			want: []string{"SELECT 'a;b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitStatements(tt.sql); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitStatements(%q)\n got %q\nwant %q", tt.sql, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		want    []Migration
+		wantErr string
+	}{
+		{
+			name: "sorted by version",
+			files: fstest.MapFS{
+				"2_add_email.up.sql":      {Data: []byte("ALTER TABLE users ADD email TEXT;")},
+				"1_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id INT);")},
+				"1_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+				"README.md":               {Data: []byte("not a migration")},
+				"archive/3_old.up.sql":    {Data: []byte("SELECT 1;")},
+			},
+			want: []Migration{
+				{Version: 1, Name: "create_users", Up: "CREATE TABLE users (id INT);", Down: "DROP TABLE users;"},
+				{Version: 2, Name: "add_email", Up: "ALTER TABLE users ADD email TEXT;"},
+			},
+		},
+		{
+			name:  "empty directory",
+			files: fstest.MapFS{},
+			want:  []Migration{},
+		},
+		{
+			name:    "invalid file name",
+			files:   fstest.MapFS{"create_users.up.sql": {}},
+			wantErr: "invalid migration file name",
+		},
+		{
+			name:    "invalid direction",
+			files:   fstest.MapFS{"1_create_users.sql": {}},
+			wantErr: "invalid migration file name",
+		},
+		{
+			name:    "zero version",
+			files:   fstest.MapFS{"0_create_users.up.sql": {}},
+			wantErr: "invalid migration version",
+		},
+		{
+			name:    "version out of range",
+			files:   fstest.MapFS{"99999999999999999999_create_users.up.sql": {}},
+			wantErr: "invalid migration version",
+		},
+		{
+			name: "duplicate version",
+			files: fstest.MapFS{
+				"1_create_users.up.sql":  {},
+				"1_create_orders.up.sql": {},
+			},
+			wantErr: "migration version 1 is used by both",
+		},
+		{
+			name:    "missing up file",
+			files:   fstest.MapFS{"1_create_users.down.sql": {}},
+			wantErr: "migration 1_create_users has no up file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Load(tt.files)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load: got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Load:\n got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
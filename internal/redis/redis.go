@@ -11,6 +11,7 @@ package redis
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -24,10 +25,18 @@ import (
 // 初级工程师学习要点：
 // - Redis 封装了 go-redis 的 UniversalClient
 // - UniversalClient 可以自动适配三种模式（standalone/sentinel/cluster）
+// - 支持运行时热更新（Reload），替换下来的旧客户端由后台 goroutine 延迟关闭
 type Redis struct {
-	name   string
+	name      string
+	healthMgr *health.Manager
+
+	mu     sync.RWMutex
 	client redis.UniversalClient
 	config config.RedisConfig
+
+	ctx    context.Context // Close 时取消，让等待中的旧客户端立即关闭
+	cancel context.CancelFunc
+	wg     sync.WaitGroup // 等待关闭的旧客户端
 }
 
 // New 创建 Redis 实例
@@ -37,7 +46,27 @@ type Redis struct {
 // - UniversalClient 是一个接口，可以统一处理三种模式
 // - 自动注册到健康检查管理器
 func New(cfg config.RedisConfig, healthMgr *health.Manager) (*Redis, error) {
-	// 创建 Redis 客户端
+	client, err := newClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Redis{
+		name:      cfg.Name,
+		healthMgr: healthMgr,
+		client:    client,
+		config:    cfg,
+	}
+	r.ctx, r.cancel = context.WithCancel(context.Background())
+
+	// 注册健康检查（如果提供了 healthMgr）
+	r.registerHealthChecker(client, cfg)
+
+	return r, nil
+}
+
+// newClient 创建 Redis 客户端并测试连接
+func newClient(cfg config.RedisConfig) (redis.UniversalClient, error) {
 	client := redis.NewUniversalClient(&redis.UniversalOptions{
 		// 根据 mode 自动选择客户端类型
 		Addrs:      getAddrs(cfg),
@@ -62,28 +91,30 @@ func New(cfg config.RedisConfig, healthMgr *health.Manager) (*Redis, error) {
 		ConnMaxIdleTime: cfg.IdleCheckFrequency,
 	})
 
+	// 注册链路追踪 Hook（每条命令生成一个 Span）
+	client.AddHook(newTracingHook(cfg.Name))
+
 	// 测试连接
 	ctx := context.Background()
 	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
 		return nil, fmt.Errorf("failed to ping redis: %w", err)
 	}
 
-	r := &Redis{
-		name:   cfg.Name,
-		client: client,
-		config: cfg,
-	}
+	return client, nil
+}
 
-	// 注册健康检查（如果提供了 healthMgr）
-	if healthMgr != nil {
-		checker := &RedisHealthChecker{
-			name:   cfg.Name,
-			client: client,
-		}
-		healthMgr.Register(checker)
+// registerHealthChecker 注册健康检查器（healthMgr 为 nil 时跳过）
+func (r *Redis) registerHealthChecker(client redis.UniversalClient, cfg config.RedisConfig) {
+	if r.healthMgr == nil {
+		return
 	}
 
-	return r, nil
+	checker := &RedisHealthChecker{
+		name:   cfg.Name,
+		client: client,
+	}
+	r.healthMgr.Register(checker, cfg.HealthCheck)
 }
 
 // getAddrs 根据配置获取 Redis 地址列表
@@ -108,13 +139,23 @@ func getAddrs(cfg config.RedisConfig) []string {
 // 初级工程师学习要点：
 // - 返回原始的 go-redis 客户端
 // - 可以使用 go-redis 的所有方法
+// - 热更新后会返回新的客户端，不要长期持有返回值
 func (r *Redis) Client() redis.UniversalClient {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	return r.client
 }
 
 // Close 关闭 Redis 连接
+//
+// 初级工程师学习要点：
+// - 热更新替换下来、还在等待关闭的旧客户端会立即关闭
 func (r *Redis) Close() error {
-	return r.client.Close()
+	r.cancel()
+	r.wg.Wait()
+
+	return r.Client().Close()
 }
 
 // Name 返回 Redis 实例名称
@@ -122,6 +163,15 @@ func (r *Redis) Name() string {
 	return r.name
 }
 
+// PoolStats 返回连接池统计
+//
+// 初级工程师学习要点：
+// - Hits/Misses 表示从连接池取连接时是否命中空闲连接
+// - Timeouts 持续增长说明连接池不够用，需要调大 pool_size
+func (r *Redis) PoolStats() *redis.PoolStats {
+	return r.Client().PoolStats()
+}
+
 // ==================== 常用操作封装 ====================
 // 以下是一些常用的 Redis 操作封装，方便使用
 
@@ -131,7 +181,7 @@ func (r *Redis) Name() string {
 // - 使用 Context 传递请求信息
 // - 如果 key 不存在，返回 redis.Nil 错误
 func (r *Redis) Get(ctx context.Context, key string) (string, error) {
-	return r.client.Get(ctx, key).Result()
+	return r.Client().Get(ctx, key).Result()
 }
 
 // Set 设置字符串值
@@ -140,117 +190,126 @@ func (r *Redis) Get(ctx context.Context, key string) (string, error) {
 // - expiration 为 0 表示永不过期
 // - 使用 time.Duration 类型表示过期时间
 func (r *Redis) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
-	return r.client.Set(ctx, key, value, expiration).Err()
+	return r.Client().Set(ctx, key, value, expiration).Err()
+}
+
+// SetNX 仅在 key 不存在时设置值，返回是否设置成功
+//
+// 初级工程师学习要点：
+// - 检查和设置在 Redis 中一步完成，多个实例并发调用时只有一个会成功
+// - 常用于分布式锁、幂等控制、一次性令牌
+func (r *Redis) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	return r.Client().SetNX(ctx, key, value, expiration).Result()
 }
 
 // Del 删除 key
 func (r *Redis) Del(ctx context.Context, keys ...string) error {
-	return r.client.Del(ctx, keys...).Err()
+	return r.Client().Del(ctx, keys...).Err()
 }
 
 // Exists 检查 key 是否存在
 func (r *Redis) Exists(ctx context.Context, keys ...string) (int64, error) {
-	return r.client.Exists(ctx, keys...).Result()
+	return r.Client().Exists(ctx, keys...).Result()
 }
 
 // Expire 设置 key 的过期时间
 func (r *Redis) Expire(ctx context.Context, key string, expiration time.Duration) error {
-	return r.client.Expire(ctx, key, expiration).Err()
+	return r.Client().Expire(ctx, key, expiration).Err()
 }
 
 // TTL 获取 key 的剩余过期时间
 func (r *Redis) TTL(ctx context.Context, key string) (time.Duration, error) {
-	return r.client.TTL(ctx, key).Result()
+	return r.Client().TTL(ctx, key).Result()
 }
 
 // Incr 自增
 func (r *Redis) Incr(ctx context.Context, key string) (int64, error) {
-	return r.client.Incr(ctx, key).Result()
+	return r.Client().Incr(ctx, key).Result()
 }
 
 // Decr 自减
 func (r *Redis) Decr(ctx context.Context, key string) (int64, error) {
-	return r.client.Decr(ctx, key).Result()
+	return r.Client().Decr(ctx, key).Result()
 }
 
 // HGet 获取 Hash 字段值
 func (r *Redis) HGet(ctx context.Context, key, field string) (string, error) {
-	return r.client.HGet(ctx, key, field).Result()
+	return r.Client().HGet(ctx, key, field).Result()
 }
 
 // HSet 设置 Hash 字段值
 func (r *Redis) HSet(ctx context.Context, key string, values ...interface{}) error {
-	return r.client.HSet(ctx, key, values...).Err()
+	return r.Client().HSet(ctx, key, values...).Err()
 }
 
 // HGetAll 获取 Hash 所有字段
 func (r *Redis) HGetAll(ctx context.Context, key string) (map[string]string, error) {
-	return r.client.HGetAll(ctx, key).Result()
+	return r.Client().HGetAll(ctx, key).Result()
 }
 
 // HDel 删除 Hash 字段
 func (r *Redis) HDel(ctx context.Context, key string, fields ...string) error {
-	return r.client.HDel(ctx, key, fields...).Err()
+	return r.Client().HDel(ctx, key, fields...).Err()
 }
 
 // LPush 从列表左侧插入
 func (r *Redis) LPush(ctx context.Context, key string, values ...interface{}) error {
-	return r.client.LPush(ctx, key, values...).Err()
+	return r.Client().LPush(ctx, key, values...).Err()
 }
 
 // RPush 从列表右侧插入
 func (r *Redis) RPush(ctx context.Context, key string, values ...interface{}) error {
-	return r.client.RPush(ctx, key, values...).Err()
+	return r.Client().RPush(ctx, key, values...).Err()
 }
 
 // LPop 从列表左侧弹出
 func (r *Redis) LPop(ctx context.Context, key string) (string, error) {
-	return r.client.LPop(ctx, key).Result()
+	return r.Client().LPop(ctx, key).Result()
 }
 
 // RPop 从列表右侧弹出
 func (r *Redis) RPop(ctx context.Context, key string) (string, error) {
-	return r.client.RPop(ctx, key).Result()
+	return r.Client().RPop(ctx, key).Result()
 }
 
 // LRange 获取列表范围内的元素
 func (r *Redis) LRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
-	return r.client.LRange(ctx, key, start, stop).Result()
+	return r.Client().LRange(ctx, key, start, stop).Result()
 }
 
 // SAdd 添加集合成员
 func (r *Redis) SAdd(ctx context.Context, key string, members ...interface{}) error {
-	return r.client.SAdd(ctx, key, members...).Err()
+	return r.Client().SAdd(ctx, key, members...).Err()
 }
 
 // SMembers 获取集合所有成员
 func (r *Redis) SMembers(ctx context.Context, key string) ([]string, error) {
-	return r.client.SMembers(ctx, key).Result()
+	return r.Client().SMembers(ctx, key).Result()
 }
 
 // SIsMember 检查是否是集合成员
 func (r *Redis) SIsMember(ctx context.Context, key string, member interface{}) (bool, error) {
-	return r.client.SIsMember(ctx, key, member).Result()
+	return r.Client().SIsMember(ctx, key, member).Result()
 }
 
 // SRem 删除集合成员
 func (r *Redis) SRem(ctx context.Context, key string, members ...interface{}) error {
-	return r.client.SRem(ctx, key, members...).Err()
+	return r.Client().SRem(ctx, key, members...).Err()
 }
 
 // ZAdd 添加有序集合成员
 func (r *Redis) ZAdd(ctx context.Context, key string, members ...redis.Z) error {
-	return r.client.ZAdd(ctx, key, members...).Err()
+	return r.Client().ZAdd(ctx, key, members...).Err()
 }
 
 // ZRange 获取有序集合范围内的成员
 func (r *Redis) ZRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
-	return r.client.ZRange(ctx, key, start, stop).Result()
+	return r.Client().ZRange(ctx, key, start, stop).Result()
 }
 
 // ZRem 删除有序集合成员
 func (r *Redis) ZRem(ctx context.Context, key string, members ...interface{}) error {
-	return r.client.ZRem(ctx, key, members...).Err()
+	return r.Client().ZRem(ctx, key, members...).Err()
 }
 
 // RedisHealthChecker Redis 健康检查器
@@ -268,18 +327,6 @@ func (c *RedisHealthChecker) Name() string {
 	return c.name
 }
 
-// Ping 执行轻量级健康检查（用于 Liveness）
-//
-// 初级工程师学习要点：
-// - Ping 只检查 Redis 连接是否存活
-// - 用于 Kubernetes Liveness Probe
-func (c *RedisHealthChecker) Ping(ctx context.Context) error {
-	if err := c.client.Ping(ctx).Err(); err != nil {
-		return fmt.Errorf("redis ping failed: %w", err)
-	}
-	return nil
-}
-
 // Check 执行完整健康检查（用于 Readiness）
 //
 // 初级工程师学习要点：
@@ -0,0 +1,107 @@
+// Package redis Redis 连接热更新
+package redis
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/jingpc/awesome-be/internal/config"
+)
+
+// 热更新的默认参数（未配置 reload 时使用）
+const (
+	defaultReloadGracePeriod   = 30 * time.Second
+	defaultReloadCheckInterval = time.Second
+)
+
+// Reload 使用新的配置热更新 Redis 客户端
+//
+// 初级工程师学习要点：
+// - 配置没有变化时什么都不做
+// - 先创建新客户端并 Ping 成功，再替换；失败时保持当前客户端不变
+// - 旧客户端等使用中的连接归还后再关闭（最多 reload.grace_period，规则与数据库相同）
+// - name 用于健康检查和监控指标，运行时不允许修改
+func (r *Redis) Reload(cfg config.RedisConfig) error {
+	if cfg.Name != r.name {
+		return fmt.Errorf("redis.name cannot be changed at runtime (%s -> %s)", r.name, cfg.Name)
+	}
+
+	r.mu.RLock()
+	unchanged := reflect.DeepEqual(r.config, cfg)
+	r.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	client, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to reload redis: %w", err)
+	}
+
+	r.mu.Lock()
+	old, oldCfg := r.client, r.config
+	r.client, r.config = client, cfg
+	r.mu.Unlock()
+
+	r.registerHealthChecker(client, cfg)
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.retire(old, oldCfg.Reload)
+	}()
+
+	return nil
+}
+
+// retire 宽限期结束后关闭旧客户端
+//
+// 架构思路：
+// - 替换前拿到旧客户端的请求还可能发送新的命令，所以旧客户端至少保留 GracePeriod
+// - 宽限期内即使所有连接都空闲也不关闭
+// - 宽限期结束后：ForceClose 为 true 时立即关闭，否则等使用中的连接归还后再关闭
+// - 服务关闭时（r.ctx 取消）不再等待，直接关闭
+func (r *Redis) retire(client redis.UniversalClient, cfg config.ReloadConfig) {
+	grace := cfg.GracePeriod
+	if grace <= 0 {
+		grace = defaultReloadGracePeriod
+	}
+	interval := cfg.CheckInterval
+	if interval <= 0 {
+		interval = defaultReloadCheckInterval
+	}
+
+	deadline := time.NewTimer(grace)
+	defer deadline.Stop()
+	select {
+	case <-r.ctx.Done():
+		client.Close()
+		return
+	case <-deadline.C:
+	}
+
+	if cfg.ForceClose {
+		client.Close()
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if s := client.PoolStats(); s.TotalConns == s.IdleConns {
+			client.Close()
+			return
+		}
+
+		select {
+		case <-r.ctx.Done():
+			client.Close()
+			return
+		case <-ticker.C:
+		}
+	}
+}
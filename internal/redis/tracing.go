@@ -0,0 +1,105 @@
+// Package redis Redis 链路追踪
+package redis
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName Redis Hook 使用的 Tracer 名称
+const tracerName = "github.com/jingpc/awesome-be/internal/redis"
+
+// tracingHook Redis 链路追踪 Hook
+//
+// 初级工程师学习要点：
+// - 实现 go-redis 的 redis.Hook 接口，在命令执行前后插入逻辑
+// - 每条命令（或每个 Pipeline）生成一个 Span，父 Span 来自调用时传入的 Context
+// - 只记录命令名，不记录参数，避免把缓存中的敏感数据写入追踪系统
+type tracingHook struct {
+	tracer trace.Tracer
+	attrs  []attribute.KeyValue
+}
+
+// newTracingHook 创建 Redis 链路追踪 Hook
+func newTracingHook(name string) *tracingHook {
+	return &tracingHook{
+		tracer: otel.Tracer(tracerName),
+		attrs: []attribute.KeyValue{
+			attribute.String("db.system", "redis"),
+			attribute.String("db.name", name),
+		},
+	}
+}
+
+// DialHook 建立连接时创建 Span
+func (h *tracingHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		ctx, span := h.tracer.Start(ctx, "redis.dial",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(h.attrs...),
+			trace.WithAttributes(attribute.String("server.address", addr)),
+		)
+		defer span.End()
+
+		conn, err := next(ctx, network, addr)
+		recordError(span, err)
+		return conn, err
+	}
+}
+
+// ProcessHook 执行单条命令时创建 Span
+func (h *tracingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, span := h.tracer.Start(ctx, "redis."+cmd.Name(),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(h.attrs...),
+			trace.WithAttributes(attribute.String("db.operation", cmd.Name())),
+		)
+		defer span.End()
+
+		err := next(ctx, cmd)
+		recordError(span, err)
+		return err
+	}
+}
+
+// ProcessPipelineHook 执行 Pipeline 时创建 Span
+func (h *tracingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		names := make([]string, 0, len(cmds))
+		for _, cmd := range cmds {
+			names = append(names, cmd.Name())
+		}
+
+		ctx, span := h.tracer.Start(ctx, "redis.pipeline",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(h.attrs...),
+			trace.WithAttributes(
+				attribute.String("db.operation", strings.Join(names, " ")),
+				attribute.Int("db.redis.num_cmd", len(cmds)),
+			),
+		)
+		defer span.End()
+
+		err := next(ctx, cmds)
+		recordError(span, err)
+		return err
+	}
+}
+
+// recordError 记录错误（key 不存在不算错误）
+func recordError(span trace.Span, err error) {
+	if err == nil || errors.Is(err, redis.Nil) {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
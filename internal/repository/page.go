@@ -0,0 +1,289 @@
+// Package repository 分页查询
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/jingpc/awesome-be/pkg/errors"
+)
+
+// Page 页码分页结果
+type Page[T any] struct {
+	Items      []T   `json:"items"`
+	Total      int64 `json:"total"`
+	Page       int   `json:"page"`
+	PageSize   int   `json:"page_size"`
+	TotalPages int   `json:"total_pages"`
+}
+
+// CursorPage 游标分页结果
+type CursorPage[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"` // 下一页的游标（没有下一页时为空）
+	HasMore    bool   `json:"has_more"`
+}
+
+// List 按页码分页查询（读从库）
+//
+// 初级工程师学习要点：
+// - 返回总数和总页数，适合管理后台等需要跳页的场景
+// - 页码很大时 OFFSET 需要扫描并丢弃前面所有的行，数据量大时使用 ListCursor
+// - 排序最后总会加上主键，保证相同排序值的记录在各页之间顺序稳定
+func (r *Repository[T]) List(ctx context.Context, q Query) (*Page[T], error) {
+	db, err := r.db()
+	if err != nil {
+		return nil, err
+	}
+
+	page := max(q.Page, 1)
+	size := r.limit(q.PageSize)
+	if page > math.MaxInt/size {
+		// (page-1)*size 会溢出（Query 也可能不经过 ParseQuery 直接构造）
+		return nil, errors.ErrInvalidParams.WithDetailf("%s is too large", paramPage)
+	}
+
+	tx, err := r.where(db.Slave(ctx).Model(new(T)), q.Filters)
+	if err != nil {
+		return nil, err
+	}
+	// Count 和 Find 使用同一个从库连接，各自生成独立的语句
+	tx = tx.Session(&gorm.Session{})
+
+	var total int64
+	if err := tx.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	items := make([]T, 0, size)
+	offset := (page - 1) * size
+	if int64(offset) < total {
+		err := tx.Order(r.orderBy(r.sortsOf(q))).Offset(offset).Limit(size).Find(&items).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Page[T]{
+		Items:      items,
+		Total:      total,
+		Page:       page,
+		PageSize:   size,
+		TotalPages: int((total + int64(size) - 1) / int64(size)),
+	}, nil
+}
+
+// ListCursor 按游标分页查询（读从库）
+//
+// 架构思路：
+// - 游标记录上一页最后一条记录的排序字段值，下一页从这些值之后开始（WHERE (a, id) > (?, ?) 的展开形式）
+// - 无论翻到第几页都走索引，不需要扫描前面的行；多查一条判断是否还有下一页
+// - 游标中带有排序方式，排序变化后旧游标会被拒绝，而不是返回错乱的数据
+//
+// 初级工程师学习要点：
+// - 适合无限滚动、数据导出等只需要下一页的场景，不返回总数
+// - 排序字段不能为 NULL（NULL 无法比较大小）
+// - 游标无效时返回 errors.ErrInvalidParams
+func (r *Repository[T]) ListCursor(ctx context.Context, q Query) (*CursorPage[T], error) {
+	db, err := r.db()
+	if err != nil {
+		return nil, err
+	}
+
+	size := r.limit(q.PageSize)
+	sorts := r.sortsOf(q)
+
+	tx, err := r.where(db.Slave(ctx).Model(new(T)), q.Filters)
+	if err != nil {
+		return nil, err
+	}
+	if q.Cursor != "" {
+		after, err := r.decodeCursor(q.Cursor, sorts)
+		if err != nil {
+			return nil, err
+		}
+		tx = tx.Where(after)
+	}
+
+	items := make([]T, 0, size+1)
+	if err := tx.Order(r.orderBy(sorts)).Limit(size + 1).Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	result := &CursorPage[T]{Items: items}
+	if len(items) > size {
+		result.Items = items[:size]
+		result.HasMore = true
+		if result.NextCursor, err = r.encodeCursor(ctx, &items[size-1], sorts); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// limit 返回每页数量（未设置时使用默认值，不超过上限）
+func (r *Repository[T]) limit(size int) int {
+	if size <= 0 {
+		return r.pageSize
+	}
+	return min(size, r.maxPageSize)
+}
+
+// where 添加过滤条件
+func (r *Repository[T]) where(tx *gorm.DB, filters []Filter) (*gorm.DB, error) {
+	for _, f := range filters {
+		expr, err := f.expression()
+		if err != nil {
+			return nil, err
+		}
+		tx = tx.Where(expr)
+	}
+	return tx, nil
+}
+
+// sortsOf 返回查询的排序（未指定时使用默认排序），最后加上主键
+func (r *Repository[T]) sortsOf(q Query) []Sort {
+	sorts := q.Sorts
+	if len(sorts) == 0 {
+		sorts = r.defaultSort
+	}
+	for _, s := range sorts {
+		if s.Column == r.primary.DBName {
+			return sorts
+		}
+	}
+	return append(sorts[:len(sorts):len(sorts)], Sort{Column: r.primary.DBName})
+}
+
+// orderBy 将排序转换为 ORDER BY
+func (r *Repository[T]) orderBy(sorts []Sort) clause.OrderBy {
+	columns := make([]clause.OrderByColumn, len(sorts))
+	for i, s := range sorts {
+		columns[i] = clause.OrderByColumn{
+			Column: clause.Column{Table: clause.CurrentTable, Name: s.Column},
+			Desc:   s.Desc,
+		}
+	}
+	return clause.OrderBy{Columns: columns}
+}
+
+// cursor 游标内容（base64 编码的 JSON）
+type cursor struct {
+	Sort   string   `json:"s"` // 排序方式，如 -created_at,id
+	Values []string `json:"v"` // 上一页最后一条记录的排序字段值
+}
+
+// sortKey 返回排序方式的文本形式
+func sortKey(sorts []Sort) string {
+	items := make([]string, len(sorts))
+	for i, s := range sorts {
+		items[i] = s.Column
+		if s.Desc {
+			items[i] = "-" + s.Column
+		}
+	}
+	return strings.Join(items, ",")
+}
+
+// encodeCursor 根据记录的排序字段值生成游标
+func (r *Repository[T]) encodeCursor(ctx context.Context, entity *T, sorts []Sort) (string, error) {
+	rv := reflect.ValueOf(entity).Elem()
+	c := cursor{Sort: sortKey(sorts), Values: make([]string, len(sorts))}
+	for i, s := range sorts {
+		value, _ := r.schema.LookUpField(s.Column).ValueOf(ctx, rv)
+		text, err := formatValue(value)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode cursor for column %s: %w", s.Column, err)
+		}
+		c.Values[i] = text
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor 解析游标，返回 "排在游标之后" 的条件
+//
+// 以 ORDER BY a, b DESC, id 为例，条件为：
+// a > ? OR (a = ? AND b < ?) OR (a = ? AND b = ? AND id > ?)
+func (r *Repository[T]) decodeCursor(text string, sorts []Sort) (clause.Expression, error) {
+	invalid := errors.ErrInvalidParams.WithDetailf("invalid %s", paramCursor)
+
+	data, err := base64.RawURLEncoding.DecodeString(text)
+	if err != nil {
+		return nil, invalid
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Sort != sortKey(sorts) || len(c.Values) != len(sorts) {
+		return nil, invalid
+	}
+
+	values := make([]any, len(sorts))
+	for i, s := range sorts {
+		field := r.schema.LookUpField(s.Column)
+		if field == nil {
+			return nil, fmt.Errorf("model %s has no column %s", r.schema.Name, s.Column)
+		}
+		if values[i], err = parseValue(field, c.Values[i]); err != nil {
+			return nil, invalid
+		}
+	}
+
+	or := make([]clause.Expression, len(sorts))
+	for i, s := range sorts {
+		and := make([]clause.Expression, 0, i+1)
+		for j := 0; j < i; j++ {
+			and = append(and, clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: sorts[j].Column}, Value: values[j]})
+		}
+		column := clause.Column{Table: clause.CurrentTable, Name: s.Column}
+		if s.Desc {
+			and = append(and, clause.Lt{Column: column, Value: values[i]})
+		} else {
+			and = append(and, clause.Gt{Column: column, Value: values[i]})
+		}
+		or[i] = clause.And(and...)
+	}
+	// 外层用 And 包裹：只有一个排序字段时 Or 只有一个条件，GORM 拼接 WHERE 时会用 OR 连接这种条件，
+	// 显式包裹后无论 GORM 是否处理，游标条件都和过滤条件 AND（不会变成 name LIKE ? OR id > ?）
+	return clause.And(clause.Or(or...)), nil
+}
+
+// formatValue 将排序字段值转换为游标中的文本（与 parseValue 对应）
+func formatValue(value any) (string, error) {
+	if valuer, ok := value.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return "", err
+		}
+		value = v
+	}
+
+	rv := reflect.ValueOf(value)
+	for rv.IsValid() && rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return "", fmt.Errorf("value is NULL")
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return "", fmt.Errorf("value is NULL")
+	}
+
+	if t, ok := rv.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339Nano), nil
+	}
+	return fmt.Sprint(rv.Interface()), nil
+}
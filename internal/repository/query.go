@@ -0,0 +1,268 @@
+// Package repository 查询参数解析
+package repository
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"github.com/jingpc/awesome-be/pkg/errors"
+)
+
+// 保留的查询参数名（其他参数都是过滤条件）
+const (
+	paramPage     = "page"
+	paramPageSize = "page_size"
+	paramSort     = "sort"
+	paramCursor   = "cursor"
+)
+
+// Operator 过滤操作符
+type Operator string
+
+// 支持的过滤操作符（查询参数写作 field[op]=value，省略 [op] 时为 eq）
+const (
+	OpEq   Operator = "eq"   // 等于
+	OpNe   Operator = "ne"   // 不等于
+	OpGt   Operator = "gt"   // 大于
+	OpGte  Operator = "gte"  // 大于等于
+	OpLt   Operator = "lt"   // 小于
+	OpLte  Operator = "lte"  // 小于等于
+	OpLike Operator = "like" // 包含（仅文本字段，% 和 _ 按普通字符匹配）
+	OpIn   Operator = "in"   // 在列表中（逗号分隔）
+	OpNull Operator = "null" // 为空（true）或不为空（false）
+)
+
+// Filter 过滤条件
+type Filter struct {
+	Column string   // 列名
+	Op     Operator // 操作符
+	Value  any      // 值（OpIn 为 []any，OpNull 为 bool）
+}
+
+// Sort 排序条件
+type Sort struct {
+	Column string // 列名
+	Desc   bool   // 是否降序
+}
+
+// Query 列表查询条件
+//
+// 初级工程师学习要点：
+// - 通常由 ParseQuery 从查询参数解析，也可以在代码中追加条件（如只查询当前用户的数据）
+// - Page 从 1 开始；使用游标分页（ListCursor）时忽略 Page，使用 Cursor
+type Query struct {
+	Filters  []Filter
+	Sorts    []Sort
+	Page     int
+	PageSize int
+	Cursor   string
+}
+
+// ParseQuery 从查询参数解析列表查询条件
+//
+// 初级工程师学习要点：
+// - 过滤：name=tom、age[gte]=18、status[in]=active,locked、deleted_at[null]=true
+// - 排序：sort=-created_at,name（- 表示降序）
+// - 分页：page=2&page_size=50 或 cursor=...（上一页返回的 next_cursor）
+// - 只能使用 Options 中声明的过滤、排序参数，值按字段类型转换（时间使用 RFC 3339 或 2006-01-02）
+// - 参数错误（包括页码大到 OFFSET 溢出）时返回 errors.ErrInvalidParams，不需要再转换
+//
+// 使用示例：
+//
+//	query, err := users.ParseQuery(c.Request.URL.Query())
+//	if err != nil {
+//		response.Error(c, err)
+//		return
+//	}
+func (r *Repository[T]) ParseQuery(values url.Values) (Query, error) {
+	q := Query{Page: 1, PageSize: r.pageSize}
+
+	// 按参数名排序，同样的请求总是得到同样的错误和条件顺序
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := values.Get(key)
+		switch key {
+		case paramPage:
+			page, err := strconv.Atoi(value)
+			if err != nil || page < 1 {
+				return Query{}, errors.ErrInvalidParams.WithDetailf("%s must be a positive integer", paramPage)
+			}
+			q.Page = page
+
+		case paramPageSize:
+			size, err := strconv.Atoi(value)
+			if err != nil || size < 1 {
+				return Query{}, errors.ErrInvalidParams.WithDetailf("%s must be a positive integer", paramPageSize)
+			}
+			q.PageSize = min(size, r.maxPageSize)
+
+		case paramSort:
+			sorts, err := r.parseSort(value)
+			if err != nil {
+				return Query{}, errors.ErrInvalidParams.WithDetail(err.Error())
+			}
+			q.Sorts = sorts
+
+		case paramCursor:
+			q.Cursor = value
+
+		default:
+			for _, v := range values[key] {
+				filter, err := r.parseFilter(key, v)
+				if err != nil {
+					return Query{}, errors.ErrInvalidParams.WithDetail(err.Error())
+				}
+				q.Filters = append(q.Filters, filter)
+			}
+		}
+	}
+
+	// 页码过大时 OFFSET = (page-1)*page_size 会溢出
+	if q.Page > math.MaxInt/q.PageSize {
+		return Query{}, errors.ErrInvalidParams.WithDetailf("%s is too large", paramPage)
+	}
+
+	return q, nil
+}
+
+// parseFilter 解析单个过滤参数（name 或 name[op]）
+func (r *Repository[T]) parseFilter(key, value string) (Filter, error) {
+	name, op := key, OpEq
+	if i := strings.IndexByte(key, '['); i >= 0 && strings.HasSuffix(key, "]") {
+		name, op = key[:i], Operator(key[i+1:len(key)-1])
+	}
+
+	field, ok := r.filters[name]
+	if !ok {
+		return Filter{}, fmt.Errorf("filtering by %s is not allowed", name)
+	}
+	filter := Filter{Column: field.DBName, Op: op}
+
+	var err error
+	switch op {
+	case OpEq, OpNe, OpGt, OpGte, OpLt, OpLte:
+		filter.Value, err = parseValue(field, value)
+	case OpLike:
+		if field.DataType != schema.String {
+			return Filter{}, fmt.Errorf("%s[%s] is only supported for text fields", name, op)
+		}
+		filter.Value = value
+	case OpIn:
+		parts := strings.Split(value, ",")
+		list := make([]any, len(parts))
+		for i, part := range parts {
+			if list[i], err = parseValue(field, part); err != nil {
+				break
+			}
+		}
+		filter.Value = list
+	case OpNull:
+		filter.Value, err = strconv.ParseBool(value)
+	default:
+		return Filter{}, fmt.Errorf("unknown operator %s in %s", op, key)
+	}
+	if err != nil {
+		return Filter{}, fmt.Errorf("invalid value for %s: %q", key, value)
+	}
+
+	return filter, nil
+}
+
+// parseSort 解析排序参数（-created_at,name）
+func (r *Repository[T]) parseSort(value string) ([]Sort, error) {
+	var sorts []Sort
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		desc := strings.HasPrefix(item, "-")
+		name := strings.TrimPrefix(item, "-")
+
+		field, ok := r.sorts[name]
+		if !ok {
+			return nil, fmt.Errorf("sorting by %s is not allowed", name)
+		}
+		if seen[field.DBName] {
+			return nil, fmt.Errorf("duplicate sort field %s", name)
+		}
+		seen[field.DBName] = true
+		sorts = append(sorts, Sort{Column: field.DBName, Desc: desc})
+	}
+	return sorts, nil
+}
+
+// parseValue 按字段类型转换查询参数的值
+func parseValue(field *schema.Field, value string) (any, error) {
+	switch field.DataType {
+	case schema.Bool:
+		return strconv.ParseBool(value)
+	case schema.Int:
+		return strconv.ParseInt(value, 10, 64)
+	case schema.Uint:
+		return strconv.ParseUint(value, 10, 64)
+	case schema.Float:
+		return strconv.ParseFloat(value, 64)
+	case schema.Time:
+		if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+			return t, nil
+		}
+		return time.ParseInLocation(time.DateOnly, value, time.Local)
+	default:
+		return value, nil
+	}
+}
+
+// expression 将过滤条件转换为 GORM 条件
+func (f Filter) expression() (clause.Expression, error) {
+	column := clause.Column{Table: clause.CurrentTable, Name: f.Column}
+	switch f.Op {
+	case OpEq:
+		return clause.Eq{Column: column, Value: f.Value}, nil
+	case OpNe:
+		return clause.Neq{Column: column, Value: f.Value}, nil
+	case OpGt:
+		return clause.Gt{Column: column, Value: f.Value}, nil
+	case OpGte:
+		return clause.Gte{Column: column, Value: f.Value}, nil
+	case OpLt:
+		return clause.Lt{Column: column, Value: f.Value}, nil
+	case OpLte:
+		return clause.Lte{Column: column, Value: f.Value}, nil
+	case OpLike:
+		// 转义通配符，使用 ! 作为转义字符（MySQL、PostgreSQL、SQLite 写法相同）
+		pattern := "%" + likeEscaper.Replace(fmt.Sprint(f.Value)) + "%"
+		return clause.Expr{SQL: "? LIKE ? ESCAPE '!'", Vars: []any{column, pattern}}, nil
+	case OpIn:
+		values, ok := f.Value.([]any)
+		if !ok {
+			return nil, fmt.Errorf("value of %s[in] must be []any", f.Column)
+		}
+		return clause.IN{Column: column, Values: values}, nil
+	case OpNull:
+		isNull, ok := f.Value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("value of %s[null] must be bool", f.Column)
+		}
+		if isNull {
+			return clause.Eq{Column: column, Value: nil}, nil
+		}
+		return clause.Neq{Column: column, Value: nil}, nil
+	default:
+		return nil, fmt.Errorf("unknown operator %s", f.Op)
+	}
+}
+
+// likeEscaper 转义 LIKE 中的通配符
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
@@ -0,0 +1,413 @@
+// Package repository 提供通用的数据访问层（Repository）
+//
+// Service 层通过 Repository[T] 读写模型，不需要手写 Table("users").Where(...) 这样的查询：
+// 读操作使用从库（Slave），写操作使用主库（Master），
+// 提供增删改查、分页（页码、游标）、白名单过滤和排序、软删除、乐观锁。
+//
+// 初级工程师学习要点：
+// - Repository 返回原始错误（gorm.ErrRecordNotFound 等），由 errors.FromError 转换为业务错误
+// - 查询参数只能按 Options 中声明的字段过滤和排序，客户端无法查询任意列
+// - ctx 中有 database.Transaction 开启的事务时，所有操作都在该事务中执行
+//
+// 使用示例：
+//
+//	type User struct {
+//		ID        int64
+//		Name      string
+//		Version   int64          // 乐观锁版本号（可选）
+//		DeletedAt gorm.DeletedAt // 软删除（可选）
+//	}
+//
+//	users, err := repository.NewWithManager[User](dbMgr, "default", repository.Options{
+//		Filters:     map[string]string{"name": "name"},
+//		Sorts:       map[string]string{"id": "id", "created_at": "created_at"},
+//		DefaultSort: "-id",
+//	})
+//
+//	query, err := users.ParseQuery(c.Request.URL.Query()) // ?name[like]=tom&sort=-created_at&page=2
+//	page, err := users.List(ctx, query)
+package repository
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"github.com/jingpc/awesome-be/internal/database"
+	"github.com/jingpc/awesome-be/pkg/errors"
+)
+
+// 分页的默认参数（Options 未设置时使用）
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// ErrVersionConflict 乐观锁冲突：记录在读取之后已被其他请求修改
+//
+// 初级工程师学习要点：
+// - 使用 errors.Is(err, repository.ErrVersionConflict) 判断，返回给客户端时是 409
+// - 客户端应该重新读取最新数据，确认后再提交修改
+var ErrVersionConflict = errors.ErrConflict.WithDetail("record was modified by another request (version conflict)")
+
+// Options 仓储配置
+type Options struct {
+	// Filters 可过滤的字段：查询参数名 -> 列名（或字段名），如 {"name": "name", "created": "created_at"}
+	Filters map[string]string
+
+	// Sorts 可排序的字段：查询参数名 -> 列名（或字段名）
+	Sorts map[string]string
+
+	// DefaultSort 未指定 sort 时的排序（使用 Sorts 中的参数名），如 "-created_at"，为空时按主键升序
+	DefaultSort string
+
+	// DefaultPageSize 未指定 page_size 时的每页数量（默认 20）
+	DefaultPageSize int
+
+	// MaxPageSize 每页数量上限，超过时按上限返回（默认 100）
+	MaxPageSize int
+}
+
+// Repository 模型 T 的通用仓储
+//
+// 架构思路：
+// - 模型结构（主键、版本号、软删除字段）在创建时解析一次，每次操作不再反射查找
+// - 每次操作时才获取 Database（NewWithManager），数据库热更新后自动使用新的连接池
+// - 过滤、排序的列使用 clause.Column，由 GORM 按数据库方言加引号，不拼接 SQL 字符串
+//
+// 初级工程师学习要点：
+// - 模型有名为 Version 的整数字段时启用乐观锁，Update 时检查并递增版本号
+// - 模型有 gorm.DeletedAt 字段时启用软删除，Delete 只设置删除时间，查询自动排除已删除记录
+type Repository[T any] struct {
+	db func() (*database.Database, error)
+
+	schema    *schema.Schema
+	primary   *schema.Field
+	version   *schema.Field // 乐观锁版本号（没有时为 nil）
+	deletedAt *schema.Field // 软删除时间（没有时为 nil）
+
+	filters     map[string]*schema.Field
+	sorts       map[string]*schema.Field
+	defaultSort []Sort
+	pageSize    int
+	maxPageSize int
+}
+
+// schemaCache 模型结构缓存（所有仓储共享）
+var schemaCache sync.Map
+
+// New 创建使用指定 Database 的仓储
+//
+// 初级工程师学习要点：
+// - 适合命令行工具等生命周期较短的场景
+// - HTTP 服务中使用 NewWithManager，数据库热更新后不会继续使用旧的连接池
+func New[T any](db *database.Database, opts Options) (*Repository[T], error) {
+	if db == nil {
+		return nil, fmt.Errorf("database is required")
+	}
+	return newRepository[T](func() (*database.Database, error) { return db, nil }, opts)
+}
+
+// NewWithManager 创建按名称使用 Manager 中数据库的仓储
+//
+// 初级工程师学习要点：
+// - 每次操作时通过 Manager.Get 获取 Database，不长期持有
+// - 数据库不存在时操作返回 errors.ErrDBError，而不是在启动时失败
+func NewWithManager[T any](mgr *database.Manager, name string, opts Options) (*Repository[T], error) {
+	return newRepository[T](func() (*database.Database, error) {
+		var db *database.Database
+		if mgr != nil {
+			db = mgr.Get(name)
+		}
+		if db == nil {
+			return nil, errors.ErrDBError.WithDetailf("%s database not found", name)
+		}
+		return db, nil
+	}, opts)
+}
+
+// Must 创建仓储失败时 panic（用法同 regexp.MustCompile）
+//
+// 初级工程师学习要点：
+// - Options 是写在代码中的常量，出错说明代码有问题，启动时就应该发现
+// - 不要用于运行时才知道的参数
+func Must[T any](r *Repository[T], err error) *Repository[T] {
+	if err != nil {
+		panic(fmt.Sprintf("repository: %v", err))
+	}
+	return r
+}
+
+// newRepository 解析模型结构、检查 Options 并创建仓储
+func newRepository[T any](db func() (*database.Database, error), opts Options) (*Repository[T], error) {
+	// 与 GORM 默认配置使用相同的命名策略（表名 users、列名 created_at）
+	s, err := schema.Parse(new(T), &schemaCache, schema.NamingStrategy{IdentifierMaxLength: 64})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse model: %w", err)
+	}
+	if s.PrioritizedPrimaryField == nil {
+		return nil, fmt.Errorf("model %s has no primary key", s.Name)
+	}
+
+	r := &Repository[T]{
+		db:          db,
+		schema:      s,
+		primary:     s.PrioritizedPrimaryField,
+		pageSize:    opts.DefaultPageSize,
+		maxPageSize: opts.MaxPageSize,
+	}
+	if r.maxPageSize <= 0 {
+		r.maxPageSize = maxPageSize
+	}
+	if r.pageSize <= 0 {
+		r.pageSize = min(defaultPageSize, r.maxPageSize)
+	}
+	if r.pageSize > r.maxPageSize {
+		return nil, fmt.Errorf("default page size %d exceeds max page size %d", r.pageSize, r.maxPageSize)
+	}
+
+	if f := s.LookUpField("Version"); f != nil {
+		switch f.DataType {
+		case schema.Int, schema.Uint:
+			r.version = f
+		}
+	}
+	for _, f := range s.Fields {
+		if f.FieldType == reflect.TypeOf(gorm.DeletedAt{}) {
+			r.deletedAt = f
+		}
+	}
+
+	if r.filters, err = lookUpFields(s, opts.Filters); err != nil {
+		return nil, fmt.Errorf("invalid filters: %w", err)
+	}
+	if r.sorts, err = lookUpFields(s, opts.Sorts); err != nil {
+		return nil, fmt.Errorf("invalid sorts: %w", err)
+	}
+	if opts.DefaultSort != "" {
+		if r.defaultSort, err = r.parseSort(opts.DefaultSort); err != nil {
+			return nil, fmt.Errorf("invalid default sort: %w", err)
+		}
+	}
+
+	return r, nil
+}
+
+// lookUpFields 将 参数名 -> 列名 转换为 参数名 -> 字段（列不存在时报错）
+func lookUpFields(s *schema.Schema, columns map[string]string) (map[string]*schema.Field, error) {
+	fields := make(map[string]*schema.Field, len(columns))
+	for param, column := range columns {
+		f := s.LookUpField(column)
+		if f == nil || f.DBName == "" {
+			return nil, fmt.Errorf("model %s has no column %s", s.Name, column)
+		}
+		fields[param] = f
+	}
+	return fields, nil
+}
+
+// Create 创建记录（写主库）
+//
+// 初级工程师学习要点：
+// - 自增主键、CreatedAt 等字段由 GORM 回填到 entity
+// - 启用乐观锁时版本号从 1 开始
+// - 唯一键冲突返回 gorm.ErrDuplicatedKey（errors.FromError 转换为 ErrDuplicate）
+func (r *Repository[T]) Create(ctx context.Context, entity *T) error {
+	db, err := r.db()
+	if err != nil {
+		return err
+	}
+
+	if r.version != nil {
+		rv := reflect.ValueOf(entity).Elem()
+		if _, zero := r.version.ValueOf(ctx, rv); zero {
+			if err := r.version.Set(ctx, rv, 1); err != nil {
+				return err
+			}
+		}
+	}
+
+	return db.Master(ctx).Create(entity).Error
+}
+
+// Get 按主键查询记录（读从库）
+//
+// 初级工程师学习要点：
+// - 记录不存在（包括已软删除）时返回 gorm.ErrRecordNotFound（errors.FromError 转换为 ErrNotFound）
+func (r *Repository[T]) Get(ctx context.Context, id any) (*T, error) {
+	db, err := r.db()
+	if err != nil {
+		return nil, err
+	}
+
+	entity := new(T)
+	if err := db.Slave(ctx).Where(r.primaryEq(id)).First(entity).Error; err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+// Update 更新记录（写主库）
+//
+// 架构思路：
+// - 不指定 fields 时更新所有字段（包括零值），指定时只更新这些字段（字段名或列名）
+// - 启用乐观锁时条件中带上读取时的版本号（WHERE id = ? AND version = ?）并将版本号加 1
+// - 记录存在却没有更新到，说明已被其他请求修改，返回 ErrVersionConflict
+//
+// 初级工程师学习要点：
+// - entity 需要是之前读取的记录（带着读取时的版本号），更新成功后版本号加 1
+// - 记录不存在时返回 gorm.ErrRecordNotFound
+// - 主键、CreatedAt、DeletedAt 不会被更新
+func (r *Repository[T]) Update(ctx context.Context, entity *T, fields ...string) error {
+	db, err := r.db()
+	if err != nil {
+		return err
+	}
+
+	selects, err := r.updateFields(fields)
+	if err != nil {
+		return err
+	}
+	tx := db.Master(ctx).Model(entity).Select(selects)
+	if len(fields) == 0 {
+		tx = tx.Omit(r.omitOnUpdate()...)
+	}
+
+	rv := reflect.ValueOf(entity).Elem()
+	var current any
+	if r.version != nil {
+		current, _ = r.version.ValueOf(ctx, rv)
+		if err := r.version.Set(ctx, rv, reflect.ValueOf(current).Convert(reflect.TypeOf(int64(0))).Int()+1); err != nil {
+			return err
+		}
+		tx = tx.Where(clause.Eq{Column: r.column(r.version), Value: current})
+	}
+
+	result := tx.Updates(entity)
+	if result.Error == nil && result.RowsAffected == 0 {
+		// 没有更新到记录：记录不存在，或版本号已经变化
+		// （MySQL 的 RowsAffected 不包括值没有变化的记录，所以没有版本号时记录存在也算成功）
+		var count int64
+		if err := db.Master(ctx).Model(new(T)).Where(r.primaryEq(r.primaryValue(ctx, rv))).Count(&count).Error; err != nil {
+			result.Error = err
+		} else if count == 0 {
+			result.Error = gorm.ErrRecordNotFound
+		} else if r.version != nil {
+			result.Error = ErrVersionConflict
+		}
+	}
+
+	if result.Error != nil && r.version != nil {
+		// 更新失败时恢复版本号，调用方可以重新读取后再提交
+		_ = r.version.Set(ctx, rv, current)
+	}
+	return result.Error
+}
+
+// updateFields 返回 Update 需要 Select 的字段
+func (r *Repository[T]) updateFields(fields []string) ([]string, error) {
+	if len(fields) == 0 {
+		return []string{"*"}, nil
+	}
+
+	selects := make([]string, 0, len(fields)+2)
+	for _, name := range fields {
+		if f := r.schema.LookUpField(name); f == nil || f.DBName == "" {
+			return nil, fmt.Errorf("model %s has no column %s", r.schema.Name, name)
+		}
+		selects = append(selects, name)
+	}
+
+	// 只更新部分字段时，版本号和 UpdatedAt 也需要更新
+	if r.version != nil {
+		selects = append(selects, r.version.DBName)
+	}
+	for _, f := range r.schema.Fields {
+		if f.AutoUpdateTime > 0 {
+			selects = append(selects, f.DBName)
+		}
+	}
+	return selects, nil
+}
+
+// omitOnUpdate 更新所有字段时排除的字段
+func (r *Repository[T]) omitOnUpdate() []string {
+	omit := []string{r.primary.DBName}
+	for _, f := range r.schema.Fields {
+		if f.AutoCreateTime > 0 || f == r.deletedAt {
+			omit = append(omit, f.DBName)
+		}
+	}
+	return omit
+}
+
+// Delete 按主键删除记录（写主库）
+//
+// 初级工程师学习要点：
+// - 模型有 gorm.DeletedAt 字段时是软删除（设置删除时间），可以用 Restore 恢复
+// - 记录不存在或已删除时返回 gorm.ErrRecordNotFound
+func (r *Repository[T]) Delete(ctx context.Context, id any) error {
+	db, err := r.db()
+	if err != nil {
+		return err
+	}
+	return affected(db.Master(ctx).Where(r.primaryEq(id)).Delete(new(T)))
+}
+
+// ForceDelete 按主键永久删除记录（包括已软删除的记录）
+func (r *Repository[T]) ForceDelete(ctx context.Context, id any) error {
+	db, err := r.db()
+	if err != nil {
+		return err
+	}
+	return affected(db.Master(ctx).Unscoped().Where(r.primaryEq(id)).Delete(new(T)))
+}
+
+// Restore 恢复软删除的记录
+//
+// 初级工程师学习要点：
+// - 模型没有 gorm.DeletedAt 字段时返回错误
+// - 记录不存在或没有被删除时返回 gorm.ErrRecordNotFound
+func (r *Repository[T]) Restore(ctx context.Context, id any) error {
+	if r.deletedAt == nil {
+		return fmt.Errorf("model %s does not support soft delete", r.schema.Name)
+	}
+
+	db, err := r.db()
+	if err != nil {
+		return err
+	}
+	return affected(db.Master(ctx).Unscoped().Model(new(T)).
+		Where(r.primaryEq(id)).
+		Where(clause.Neq{Column: r.column(r.deletedAt), Value: nil}).
+		Update(r.deletedAt.DBName, nil))
+}
+
+// affected 没有影响任何记录时返回 gorm.ErrRecordNotFound
+func affected(result *gorm.DB) error {
+	if result.Error == nil && result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return result.Error
+}
+
+// column 返回当前表的列（由 GORM 加引号）
+func (r *Repository[T]) column(f *schema.Field) clause.Column {
+	return clause.Column{Table: clause.CurrentTable, Name: f.DBName}
+}
+
+// primaryEq 返回 主键 = id 条件
+func (r *Repository[T]) primaryEq(id any) clause.Expression {
+	return clause.Eq{Column: r.column(r.primary), Value: id}
+}
+
+// primaryValue 返回记录的主键值
+func (r *Repository[T]) primaryValue(ctx context.Context, rv reflect.Value) any {
+	value, _ := r.primary.ValueOf(ctx, rv)
+	return value
+}
@@ -0,0 +1,257 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/jingpc/awesome-be/internal/database/databasetest"
+	"github.com/jingpc/awesome-be/pkg/errors"
+)
+
+// testUser 测试模型
+type testUser struct {
+	ID        int64
+	Name      string
+	Age       int
+	Version   int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt
+}
+
+// testEvent 没有软删除字段的测试模型（GORM 的软删除会重新组合 WHERE 条件，需要单独覆盖）
+type testEvent struct {
+	ID   int64
+	Name string
+	Age  int
+}
+
+// newTestRepository 创建仓储并写入 count 条记录（user0、user1 ...，age 为 i%3）
+func newTestRepository[T any](t *testing.T, count int) *Repository[T] {
+	t.Helper()
+
+	db := databasetest.NewSQLite(t)
+	ctx := context.Background()
+	if err := db.Master(ctx).AutoMigrate(new(T)); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := New[T](db, Options{
+		Filters:         map[string]string{"name": "name", "age": "age"},
+		Sorts:           map[string]string{"id": "id", "age": "age", "name": "name"},
+		DefaultPageSize: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < count; i++ {
+		err := db.Master(ctx).Model(new(T)).Create(map[string]any{"name": fmt.Sprintf("user%d", i), "age": i % 3}).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return repo
+}
+
+// listAllCursor 按游标翻页直到没有下一页，返回所有记录的 ID
+func listAllCursor[T any](t *testing.T, repo *Repository[T], query string) []int64 {
+	t.Helper()
+
+	values, _ := url.ParseQuery(query)
+	q, err := repo.ParseQuery(values)
+	if err != nil {
+		t.Fatalf("ParseQuery(%q): %v", query, err)
+	}
+
+	var ids []int64
+	for pages := 0; ; pages++ {
+		if pages > 100 {
+			t.Fatalf("%q: too many pages", query)
+		}
+		page, err := repo.ListCursor(context.Background(), q)
+		if err != nil {
+			t.Fatalf("ListCursor(%q): %v", query, err)
+		}
+		for i := range page.Items {
+			id, _ := repo.primary.ValueOf(context.Background(), reflect.ValueOf(&page.Items[i]).Elem())
+			ids = append(ids, id.(int64))
+		}
+		if !page.HasMore {
+			return ids
+		}
+		q.Cursor = page.NextCursor
+	}
+}
+
+func TestListCursorWithFilters(t *testing.T) {
+	// ID 从 1 开始，age = (id-1) % 3
+	tests := []struct {
+		query string
+		want  []int64
+	}{
+		// 默认排序只有主键，游标条件只有一个，需要与过滤条件 AND
+		{query: "age=1", want: []int64{2, 5, 8}},
+		{query: "age[in]=0,2", want: []int64{1, 3, 4, 6, 7, 9, 10}},
+		{query: "name[like]=user1", want: []int64{2}},
+		{query: "sort=-id&age[gte]=1", want: []int64{9, 8, 6, 5, 3, 2}},
+		{query: "sort=age,-id&age[ne]=2", want: []int64{10, 7, 4, 1, 8, 5, 2}},
+		{query: "", want: []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+	}
+	users := newTestRepository[testUser](t, 10)
+	events := newTestRepository[testEvent](t, 10)
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := listAllCursor(t, users, tt.query); !slices.Equal(got, tt.want) {
+				t.Errorf("soft delete model: got %v, want %v", got, tt.want)
+			}
+			if got := listAllCursor(t, events, tt.query); !slices.Equal(got, tt.want) {
+				t.Errorf("model without soft delete: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListCursorRejectsCursorForDifferentSort(t *testing.T) {
+	repo := newTestRepository[testEvent](t, 5)
+	ctx := context.Background()
+
+	q, _ := repo.ParseQuery(url.Values{"sort": {"age"}})
+	page, err := repo.ListCursor(ctx, q)
+	if err != nil || page.NextCursor == "" {
+		t.Fatalf("ListCursor: %v, cursor %q", err, page.NextCursor)
+	}
+
+	q, _ = repo.ParseQuery(url.Values{"cursor": {page.NextCursor}})
+	if _, err := repo.ListCursor(ctx, q); err == nil {
+		t.Fatal("expected error for cursor created with a different sort")
+	}
+}
+
+// isInvalidParams 检查是否为参数错误（WithDetail 返回副本，需要比较错误码）
+func isInvalidParams(err error) bool {
+	var e *errors.Error
+	return errors.As(err, &e) && e.Code == errors.ErrInvalidParams.Code
+}
+
+func TestPageOverflow(t *testing.T) {
+	repo := newTestRepository[testEvent](t, 0)
+
+	// 页码大到 (page-1)*page_size 溢出时拒绝，而不是生成负数的 OFFSET
+	values := url.Values{"page": {strconv.Itoa(math.MaxInt)}, "page_size": {"2"}}
+	if _, err := repo.ParseQuery(values); !isInvalidParams(err) {
+		t.Errorf("ParseQuery: got error %v, want ErrInvalidParams", err)
+	}
+	if _, err := repo.List(context.Background(), Query{Page: math.MaxInt}); !isInvalidParams(err) {
+		t.Errorf("List: got error %v, want ErrInvalidParams", err)
+	}
+}
+
+func TestUpdateVersionConflict(t *testing.T) {
+	repo := newTestRepository[testUser](t, 0)
+	ctx := context.Background()
+
+	user := &testUser{Name: "tom"}
+	if err := repo.Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+
+	// 两个请求读取同一条记录
+	first, err := repo.Get(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := repo.Get(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first.Name = "first"
+	if err := repo.Update(ctx, first); err != nil {
+		t.Fatalf("first update: %v", err)
+	}
+	if first.Version != 2 {
+		t.Errorf("version after update = %d, want 2", first.Version)
+	}
+
+	// 第二个请求带着旧版本号提交，冲突后版本号恢复，不覆盖第一个请求的修改
+	second.Name = "second"
+	if err := repo.Update(ctx, second, "name"); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("second update: got error %v, want ErrVersionConflict", err)
+	}
+	if second.Version != 1 {
+		t.Errorf("version after conflict = %d, want 1", second.Version)
+	}
+	got, err := repo.Get(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "first" || got.Version != 2 {
+		t.Errorf("got %s (version %d), want first (version 2)", got.Name, got.Version)
+	}
+
+	// 记录不存在时返回 gorm.ErrRecordNotFound，而不是版本冲突
+	missing := &testUser{ID: user.ID + 1, Name: "missing", Version: 1}
+	if err := repo.Update(ctx, missing); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("update missing record: got error %v, want ErrRecordNotFound", err)
+	}
+}
+
+func TestDeleteAndRestore(t *testing.T) {
+	repo := newTestRepository[testUser](t, 3)
+	ctx := context.Background()
+
+	// ids 返回 List 可见的记录
+	ids := func() []int64 {
+		t.Helper()
+		page, err := repo.List(ctx, Query{PageSize: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := make([]int64, len(page.Items))
+		for i, u := range page.Items {
+			ids[i] = u.ID
+		}
+		return ids
+	}
+
+	if err := repo.Delete(ctx, 2); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if got := ids(); !slices.Equal(got, []int64{1, 3}) {
+		t.Errorf("after delete: got %v, want [1 3]", got)
+	}
+	if _, err := repo.Get(ctx, 2); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Get deleted record: got error %v, want ErrRecordNotFound", err)
+	}
+	if err := repo.Delete(ctx, 2); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Delete twice: got error %v, want ErrRecordNotFound", err)
+	}
+
+	if err := repo.Restore(ctx, 2); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if got := ids(); !slices.Equal(got, []int64{1, 2, 3}) {
+		t.Errorf("after restore: got %v, want [1 2 3]", got)
+	}
+	if err := repo.Restore(ctx, 2); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Restore a record that is not deleted: got error %v, want ErrRecordNotFound", err)
+	}
+
+	// ForceDelete 之后无法恢复
+	if err := repo.ForceDelete(ctx, 2); err != nil {
+		t.Fatalf("ForceDelete: %v", err)
+	}
+	if err := repo.Restore(ctx, 2); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Restore after force delete: got error %v, want ErrRecordNotFound", err)
+	}
+}
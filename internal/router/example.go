@@ -5,6 +5,7 @@ import (
 	"github.com/gin-gonic/gin"
 	exampleHandler "github.com/jingpc/awesome-be/internal/handler/example"
 	exampleService "github.com/jingpc/awesome-be/internal/service/example"
+	"github.com/jingpc/awesome-be/pkg/middleware"
 )
 
 // SetupExampleRoutes 设置示例路由
@@ -40,7 +41,15 @@ func SetupExampleRoutes(group *gin.RouterGroup, cfg *RouterConfig) {
 		// 数据库错误示例
 		exampleGroup.GET("/db-error", handler.DBError)
 
+		// 分页查询示例（过滤、排序、分页参数见 Repository.ParseQuery）
+		exampleGroup.GET("/users", handler.ListUsers)
+
 		// 404 错误示例
 		exampleGroup.GET("/not-found", handler.NotFound)
+
+		// JWT 认证示例（仅在配置了 jwt.secret 时注册）
+		if cfg.JWT != nil {
+			exampleGroup.GET("/me", middleware.JWTAuth(cfg.JWT), handler.Me)
+		}
 	}
 }
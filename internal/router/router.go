@@ -14,8 +14,10 @@ package router
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/jingpc/awesome-be/internal/database"
+	"github.com/jingpc/awesome-be/internal/health"
 	"github.com/jingpc/awesome-be/internal/logger"
 	"github.com/jingpc/awesome-be/internal/redis"
+	"github.com/jingpc/awesome-be/pkg/middleware"
 )
 
 // RouterConfig 路由配置
@@ -28,6 +30,8 @@ type RouterConfig struct {
 	Logger *logger.Logger    // 日志管理器
 	DB     *database.Manager // 数据库管理器
 	Redis  *redis.Redis      // Redis 客户端
+	Health *health.Manager   // 健康检查管理器
+	JWT    *middleware.JWT   // JWT 令牌管理器（未配置 jwt.secret 时为 nil）
 }
 
 // Setup 设置所有路由
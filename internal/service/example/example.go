@@ -14,17 +14,36 @@ package example
 
 import (
 	"context"
+	"net/url"
+	"time"
+
+	"gorm.io/gorm"
 
 	"github.com/jingpc/awesome-be/internal/database"
 	"github.com/jingpc/awesome-be/internal/logger"
 	"github.com/jingpc/awesome-be/internal/redis"
-	"github.com/jingpc/awesome-be/pkg/errors"
+	"github.com/jingpc/awesome-be/internal/repository"
 )
 
+// User 用户模型（对应 users 表）
+//
+// 初级工程师学习要点：
+// - Version 用于乐观锁，并发修改同一用户时后提交的请求返回冲突
+// - DeletedAt 用于软删除，删除后查询自动排除
+type User struct {
+	ID        int64          `json:"id"`
+	Name      string         `json:"name"`
+	Email     string         `json:"email"`
+	Version   int64          `json:"version"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-"`
+}
+
 // Service 示例服务
 type Service struct {
 	logger *logger.Logger
-	db     *database.Manager
+	users  *repository.Repository[User]
 	redis  *redis.Redis
 }
 
@@ -35,9 +54,16 @@ type Service struct {
 // - 便于单元测试
 // - 便于替换实现
 func NewService(logger *logger.Logger, db *database.Manager, redis *redis.Redis) *Service {
+	// 每次操作时才从 Manager 获取 default 数据库，未配置时返回 ErrDBError
+	users := repository.Must(repository.NewWithManager[User](db, "default", repository.Options{
+		Filters:     map[string]string{"name": "name", "email": "email", "created_at": "created_at"},
+		Sorts:       map[string]string{"id": "id", "name": "name", "created_at": "created_at"},
+		DefaultSort: "-id",
+	}))
+
 	return &Service{
 		logger: logger,
-		db:     db,
+		users:  users,
 		redis:  redis,
 	}
 }
@@ -50,57 +76,44 @@ func NewService(logger *logger.Logger, db *database.Manager, redis *redis.Redis)
 // - GORM 错误会被自动转换为业务错误
 //
 // 初级工程师学习要点：
-// - 使用 WithContext 传递上下文
-// - 检查数据库连接是否存在
+// - 通过 Repository 访问数据库，读操作自动使用从库
 // - 返回错误而不是记录日志
-// - 读操作使用 Slave() 方法
+// - GORM 错误由 response.Error 自动转换为业务错误（如 gorm.ErrRecordNotFound -> errors.ErrNotFound）
 //
 // 高级工程师思考：
 // - 如何处理事务？
 // - 如何优化查询性能？
 // - 如何实现缓存？
-func (s *Service) GetUser(ctx context.Context, id int) error {
-	// 获取数据库连接
-	dbInstance := s.db.Get("default")
-	if dbInstance == nil {
-		return errors.ErrDBError.WithDetail("default database not found")
-	}
+func (s *Service) GetUser(ctx context.Context, id int64) (*User, error) {
+	return s.users.Get(ctx, id)
+}
 
-	// 定义用户结构
-	var user struct {
-		ID   int
-		Name string
+// ListUsers 分页查询用户
+//
+// 初级工程师学习要点：
+// - 查询参数由 Repository 解析，只能按 Options 中声明的字段过滤、排序
+// - 如：?name[like]=tom&sort=-created_at&page=2&page_size=20
+func (s *Service) ListUsers(ctx context.Context, params url.Values) (*repository.Page[User], error) {
+	query, err := s.users.ParseQuery(params)
+	if err != nil {
+		return nil, err
 	}
-
-	// 查询用户（读操作使用从库）
-	// GORM 错误会被自动转换为业务错误：
-	// - gorm.ErrRecordNotFound -> errors.ErrNotFound
-	// - gorm.ErrDuplicatedKey -> errors.ErrDuplicate
-	// - 其他错误 -> errors.ErrDBError
-	err := dbInstance.Slave(ctx).
-		Table("users").
-		Where("id = ?", id).
-		First(&user).Error
-
-	return err
+	return s.users.List(ctx, query)
 }
 
 // TODO: 其他业务方法示例
 //
 // CreateUser 创建用户
-// func (s *Service) CreateUser(ctx context.Context, name string) error {
-//     // 业务逻辑
-//     return nil
+// func (s *Service) CreateUser(ctx context.Context, user *User) error {
+//     return s.users.Create(ctx, user)
 // }
 //
-// UpdateUser 更新用户
-// func (s *Service) UpdateUser(ctx context.Context, id int, name string) error {
-//     // 业务逻辑
-//     return nil
+// UpdateUser 更新用户（乐观锁冲突时返回 repository.ErrVersionConflict）
+// func (s *Service) UpdateUser(ctx context.Context, user *User) error {
+//     return s.users.Update(ctx, user)
 // }
 //
-// DeleteUser 删除用户
-// func (s *Service) DeleteUser(ctx context.Context, id int) error {
-//     // 业务逻辑
-//     return nil
+// DeleteUser 删除用户（软删除）
+// func (s *Service) DeleteUser(ctx context.Context, id int64) error {
+//     return s.users.Delete(ctx, id)
 // }
@@ -0,0 +1,10 @@
+package tracing
+
+import sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+// NewWithExporter 使用指定的 Exporter 创建链路追踪（全部采样，同步导出，Span 结束后立即可见）
+//
+// 内存 Exporter 只用于测试，不能通过配置选择
+func NewWithExporter(serviceName string, exporter sdktrace.SpanExporter) *Tracing {
+	return newTracing(1, serviceName, "test", sdktrace.WithSyncer(exporter))
+}
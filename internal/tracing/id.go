@@ -0,0 +1,44 @@
+// Package tracing TraceID 生成
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// upstreamTraceIDKey 是上游 TraceID 在 context 中的键类型
+type upstreamTraceIDKey struct{}
+
+// WithUpstreamTraceID 指定新建根 Span 使用的 TraceID
+//
+// 初级工程师学习要点：
+// - 上游没有传 traceparent、只传了自定义 TraceID 请求头时使用
+// - 只影响根 Span，已有父 Span 时 TraceID 始终继承父 Span
+func WithUpstreamTraceID(ctx context.Context, traceID trace.TraceID) context.Context {
+	return context.WithValue(ctx, upstreamTraceIDKey{}, traceID)
+}
+
+// idGenerator 支持沿用上游 TraceID 的 ID 生成器
+//
+// 初级工程师学习要点：
+// - 实现 sdktrace.IDGenerator 接口
+// - 与默认生成器相比，只多了"从 context 读取上游 TraceID"这一步
+type idGenerator struct{}
+
+// NewIDs 生成根 Span 的 TraceID 和 SpanID
+func (g idGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	traceID, ok := ctx.Value(upstreamTraceIDKey{}).(trace.TraceID)
+	if !ok || !traceID.IsValid() {
+		_, _ = rand.Read(traceID[:])
+	}
+	return traceID, g.NewSpanID(ctx, traceID)
+}
+
+// NewSpanID 生成子 Span 的 SpanID
+func (g idGenerator) NewSpanID(ctx context.Context, traceID trace.TraceID) trace.SpanID {
+	var spanID trace.SpanID
+	_, _ = rand.Read(spanID[:])
+	return spanID
+}
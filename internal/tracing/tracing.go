@@ -0,0 +1,117 @@
+// Package tracing 提供分布式链路追踪功能
+//
+// 链路追踪模块基于 OpenTelemetry 实现，为 HTTP 请求、数据库语句和 Redis 命令创建 Span，
+// 并通过 W3C Trace Context（traceparent/tracestate）在服务之间传递追踪上下文。
+//
+// 初级工程师学习要点：
+// - Trace 是一次完整的请求链路，由多个 Span 组成
+// - Span 是链路中的一个操作（如一次 HTTP 请求、一条 SQL）
+// - Exporter 负责把 Span 发送到后端（Jaeger、Tempo 等）
+// - 使用全局 TracerProvider，各模块通过 otel.Tracer 获取 Tracer，无需显式传递
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/jingpc/awesome-be/internal/config"
+)
+
+// Exporter 类型
+const (
+	ExporterNone   = "none"   // 只生成 TraceID/SpanID，不导出
+	ExporterStdout = "stdout" // 输出到标准输出（调试用）
+	ExporterOTLP   = "otlp"   // 通过 OTLP/HTTP 发送到采集器
+)
+
+// Tracing 链路追踪
+//
+// 初级工程师学习要点：
+// - 封装 OpenTelemetry 的 TracerProvider，负责初始化和关闭
+// - 应用退出时必须调用 Shutdown，确保缓冲中的 Span 被导出
+type Tracing struct {
+	provider *sdktrace.TracerProvider
+}
+
+// New 创建链路追踪并注册为全局 TracerProvider
+//
+// 初级工程师学习要点：
+// - serviceName 通常是应用名称，用于在追踪后端区分服务
+// - 采样策略为 ParentBased：上游已采样则跟随，否则按 SampleRatio 采样
+// - 同时注册 W3C Trace Context 传播器，用于解析和生成 traceparent/tracestate
+func New(cfg config.TraceConfig, serviceName, env string) (*Tracing, error) {
+	var opts []sdktrace.TracerProviderOption
+
+	switch cfg.Exporter {
+	case ExporterNone, "":
+		// 不导出，只生成 TraceID/SpanID
+
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create stdout exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+
+	case ExporterOTLP:
+		clientOpts := []otlptracehttp.Option{
+			otlptracehttp.WithEndpoint(cfg.Endpoint),
+		}
+		if cfg.Insecure {
+			clientOpts = append(clientOpts, otlptracehttp.WithInsecure())
+		}
+		exporter, err := otlptracehttp.New(context.Background(), clientOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create otlp exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+
+	default:
+		return nil, fmt.Errorf("unsupported trace exporter: %s", cfg.Exporter)
+	}
+
+	return newTracing(cfg.SampleRatio, serviceName, env, opts...), nil
+}
+
+// newTracing 创建 TracerProvider 并注册为全局实例（opts 为 Exporter 等额外选项，测试中传入内存 Exporter）
+func newTracing(sampleRatio float64, serviceName, env string, opts ...sdktrace.TracerProviderOption) *Tracing {
+	res := resource.NewSchemaless(
+		attribute.String("service.name", serviceName),
+		attribute.String("deployment.environment", env),
+	)
+
+	opts = append([]sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithIDGenerator(idGenerator{}),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+	}, opts...)
+
+	t := &Tracing{provider: sdktrace.NewTracerProvider(opts...)}
+
+	otel.SetTracerProvider(t.provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return t
+}
+
+// Shutdown 导出剩余 Span 并关闭 TracerProvider
+func (t *Tracing) Shutdown(ctx context.Context) error {
+	return t.provider.Shutdown(ctx)
+}
+
+// Close 关闭链路追踪（用于优雅关闭流程）
+func (t *Tracing) Close() error {
+	return t.Shutdown(context.Background())
+}
@@ -0,0 +1,263 @@
+package tracing_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	goredis "github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/jingpc/awesome-be/internal/config"
+	"github.com/jingpc/awesome-be/internal/database/databasetest"
+	"github.com/jingpc/awesome-be/internal/redis"
+	"github.com/jingpc/awesome-be/internal/tracing"
+	"github.com/jingpc/awesome-be/pkg/middleware"
+)
+
+// 上游服务传入的 traceparent
+const (
+	upstreamTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	upstreamSpanID  = "00f067aa0ba902b7"
+)
+
+// fakeRedis 最简单的 RESP 服务端：HELLO 返回错误（客户端退回 RESP2），GET 返回 nil，PING 返回 PONG，其他命令返回 OK
+func fakeRedis(t *testing.T) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveRedis(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// serveRedis 处理一个连接上的命令
+func serveRedis(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+
+		reply := "+OK\r\n"
+		switch strings.ToUpper(args[0]) {
+		case "HELLO":
+			reply = "-ERR unknown command 'HELLO'\r\n"
+		case "PING":
+			reply = "+PONG\r\n"
+		case "GET":
+			reply = "$-1\r\n"
+		}
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+// readCommand 读取一条 RESP 命令（数组形式的批量字符串）
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("unexpected command %q", line)
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, fmt.Errorf("unexpected argument %q", line)
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+// newTestServer 创建带链路追踪的 HTTP 服务，处理请求时查询 SQLite 和 Redis
+func newTestServer(t *testing.T) http.Handler {
+	t.Helper()
+
+	db := databasetest.NewSQLite(t)
+
+	rdb, err := redis.New(config.RedisConfig{Name: "cache", Mode: "standalone", Addr: fakeRedis(t)}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { rdb.Close() })
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(middleware.Trace(config.TraceConfig{Enabled: true, Header: "X-Trace-ID"}))
+	engine.GET("/users/:id", func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		var n int64
+		if err := db.Master(ctx).Table("sqlite_master").Count(&n).Error; err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		if _, err := rdb.Get(ctx, "user:"+c.Param("id")); err != nil && !errors.Is(err, goredis.Nil) {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+	return engine
+}
+
+// findSpan 按名称前缀查找 Span
+func findSpan(t *testing.T, spans tracetest.SpanStubs, prefix string) tracetest.SpanStub {
+	t.Helper()
+
+	for _, span := range spans {
+		if strings.HasPrefix(span.Name, prefix) {
+			return span
+		}
+	}
+	names := make([]string, len(spans))
+	for i, span := range spans {
+		names[i] = span.Name
+	}
+	t.Fatalf("no span with prefix %q in %v", prefix, names)
+	return tracetest.SpanStub{}
+}
+
+// attr 返回 Span 属性的值
+func attr(span tracetest.SpanStub, key attribute.Key) string {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value.Emit()
+		}
+	}
+	return ""
+}
+
+func TestRequestSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tr := tracing.NewWithExporter("test", exporter)
+	t.Cleanup(func() { tr.Shutdown(context.Background()) })
+
+	handler := newTestServer(t)
+
+	tests := []struct {
+		name       string
+		header     http.Header
+		wantTrace  string // 期望的 TraceID（空表示新生成）
+		wantParent string // 期望的 HTTP Span 父 SpanID（空表示根 Span）
+	}{
+		{
+			name:       "traceparent",
+			header:     http.Header{"Traceparent": {"00-" + upstreamTraceID + "-" + upstreamSpanID + "-01"}},
+			wantTrace:  upstreamTraceID,
+			wantParent: upstreamSpanID,
+		},
+		{
+			name:      "custom trace id header",
+			header:    http.Header{"X-Trace-Id": {upstreamTraceID}},
+			wantTrace: upstreamTraceID,
+		},
+		{
+			name: "new trace",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+
+			req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+			for key, values := range tt.header {
+				req.Header[key] = values
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status %d", w.Code)
+			}
+
+			spans := exporter.GetSpans()
+
+			// HTTP 服务端 Span
+			server := findSpan(t, spans, "GET /users/:id")
+			if server.SpanKind != trace.SpanKindServer {
+				t.Errorf("http span kind = %v, want server", server.SpanKind)
+			}
+			traceID := server.SpanContext.TraceID().String()
+			if tt.wantTrace != "" && traceID != tt.wantTrace {
+				t.Errorf("trace id = %s, want %s", traceID, tt.wantTrace)
+			}
+			if got := server.Parent.SpanID(); tt.wantParent != "" && got.String() != tt.wantParent {
+				t.Errorf("http span parent = %s, want %s", got, tt.wantParent)
+			} else if tt.wantParent == "" && got.IsValid() {
+				t.Errorf("http span has parent %s, want a root span", got)
+			}
+			if got := attr(server, "http.route"); got != "/users/:id" {
+				t.Errorf("http.route = %q", got)
+			}
+
+			// GORM 和 Redis 的 Span 是 HTTP Span 的子 Span
+			for _, tc := range []struct {
+				prefix, system string
+			}{
+				{prefix: "gorm.", system: "sqlite"},
+				{prefix: "redis.get", system: "redis"},
+			} {
+				child := findSpan(t, spans, tc.prefix)
+				if child.Parent.SpanID() != server.SpanContext.SpanID() || child.SpanContext.TraceID() != server.SpanContext.TraceID() {
+					t.Errorf("%s span is not a child of the http span", child.Name)
+				}
+				if child.SpanKind != trace.SpanKindClient {
+					t.Errorf("%s span kind = %v, want client", child.Name, child.SpanKind)
+				}
+				if got := attr(child, "db.system"); got != tc.system {
+					t.Errorf("%s db.system = %q, want %q", child.Name, got, tc.system)
+				}
+			}
+
+			// 响应头回写 TraceID 和 traceparent（下游可以接着这条链路）
+			if got := w.Header().Get("X-Trace-ID"); got != traceID {
+				t.Errorf("X-Trace-ID = %q, want %s", got, traceID)
+			}
+			want := "00-" + traceID + "-" + server.SpanContext.SpanID().String() + "-01"
+			if got := w.Header().Get("traceparent"); got != want {
+				t.Errorf("traceparent = %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -34,6 +34,12 @@ func FromError(err error) *Error {
 	if errors.Is(err, gorm.ErrDuplicatedKey) {
 		return ErrDuplicate.WithError(err)
 	}
+	if errors.Is(err, gorm.ErrForeignKeyViolated) {
+		return ErrConflict.WithError(err)
+	}
+	if errors.Is(err, gorm.ErrCheckConstraintViolated) {
+		return ErrInvalidParams.WithError(err)
+	}
 	if errors.Is(err, gorm.ErrInvalidTransaction) {
 		return ErrDBTxError.WithError(err)
 	}
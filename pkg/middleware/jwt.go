@@ -0,0 +1,421 @@
+// Package middleware JWT 认证中间件
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/jingpc/awesome-be/internal/config"
+	"github.com/jingpc/awesome-be/internal/redis"
+	"github.com/jingpc/awesome-be/pkg/errors"
+	"github.com/jingpc/awesome-be/pkg/response"
+)
+
+// Token 类型
+const (
+	TokenTypeAccess  = "access"  // 访问令牌
+	TokenTypeRefresh = "refresh" // 刷新令牌
+)
+
+// ClaimsKey 是 Claims 在 gin.Context 中的键
+const ClaimsKey = "jwt_claims"
+
+// claimsContextKey 是 Claims 在 context.Context 中的键类型
+type claimsContextKey struct{}
+
+// Claims JWT 载荷
+//
+// 初级工程师学习要点：
+// - RegisteredClaims 包含标准字段：sub（主体）、iss（签发者）、exp（过期时间）、jti（唯一 ID）等
+// - TokenType 区分访问令牌和刷新令牌，防止刷新令牌被当作访问令牌使用
+// - Extra 存放业务自定义字段（如角色、租户 ID）
+type Claims struct {
+	jwt.RegisteredClaims
+	TokenType string                 `json:"typ"`
+	Extra     map[string]interface{} `json:"ext,omitempty"`
+}
+
+// TokenPair 访问令牌和刷新令牌
+type TokenPair struct {
+	AccessToken      string    `json:"access_token"`
+	RefreshToken     string    `json:"refresh_token"`
+	TokenType        string    `json:"token_type"` // 固定为 Bearer
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// RevocationStore 令牌吊销存储
+//
+// 初级工程师学习要点：
+// - 刷新令牌轮换（Rotation）：每次刷新都签发新的刷新令牌，并吊销旧的
+// - 旧令牌被再次使用说明可能已泄露，直接拒绝
+// - 吊销记录只需保留到令牌自然过期为止
+type RevocationStore interface {
+	// Revoke 吊销令牌，ttl 为令牌剩余有效期
+	Revoke(ctx context.Context, jti string, ttl time.Duration) error
+
+	// RevokeOnce 令牌未被吊销时吊销它，返回本次调用是否完成了吊销
+	// （检查和吊销必须是一个原子操作，已被吊销时返回 false）
+	RevokeOnce(ctx context.Context, jti string, ttl time.Duration) (bool, error)
+
+	// IsRevoked 检查令牌是否已被吊销
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// JWT JWT 令牌管理器
+//
+// 初级工程师学习要点：
+// - 负责签发、解析和刷新令牌
+// - 使用 HS256 对称签名，密钥来自 config.JWTConfig.Secret
+// - 通过构造函数注入吊销存储，多实例部署时应使用 Redis 存储
+type JWT struct {
+	config config.JWTConfig
+	secret []byte
+	store  RevocationStore
+}
+
+// NewJWT 创建 JWT 令牌管理器
+//
+// 初级工程师学习要点：
+// - store 为 nil 时使用进程内存储（仅适合单实例或开发环境）
+func NewJWT(cfg config.JWTConfig, store RevocationStore) (*JWT, error) {
+	if cfg.Secret == "" {
+		return nil, fmt.Errorf("jwt secret is required")
+	}
+
+	if store == nil {
+		store = NewMemoryRevocationStore()
+	}
+
+	return &JWT{
+		config: cfg,
+		secret: []byte(cfg.Secret),
+		store:  store,
+	}, nil
+}
+
+// GenerateTokenPair 签发访问令牌和刷新令牌
+//
+// 初级工程师学习要点：
+// - subject 通常是用户 ID
+// - 访问令牌有效期短（Expire），刷新令牌有效期长（RefreshExpire）
+// - 每个令牌都有唯一的 jti，用于吊销
+func (j *JWT) GenerateTokenPair(subject string, extra map[string]interface{}) (*TokenPair, error) {
+	now := time.Now()
+
+	accessToken, accessExp, err := j.sign(subject, TokenTypeAccess, extra, now, time.Duration(j.config.Expire)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, refreshExp, err := j.sign(subject, TokenTypeRefresh, extra, now, time.Duration(j.config.RefreshExpire)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		TokenType:        "Bearer",
+		ExpiresAt:        accessExp,
+		RefreshExpiresAt: refreshExp,
+	}, nil
+}
+
+// sign 签发单个令牌
+func (j *JWT) sign(subject, tokenType string, extra map[string]interface{}, now time.Time, ttl time.Duration) (string, time.Time, error) {
+	jti, err := newTokenID()
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate token id: %w", err)
+	}
+
+	expiresAt := now.Add(ttl)
+	claims := &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Subject:   subject,
+			Issuer:    j.config.Issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		TokenType: tokenType,
+		Extra:     extra,
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(j.secret)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	return token, expiresAt, nil
+}
+
+// ParseAccessToken 解析并验证访问令牌
+func (j *JWT) ParseAccessToken(tokenString string) (*Claims, error) {
+	return j.parse(tokenString, TokenTypeAccess)
+}
+
+// parse 解析并验证令牌
+//
+// 初级工程师学习要点：
+// - 限定签名算法，防止 alg=none 等算法替换攻击
+// - 校验签发者和过期时间
+// - 错误统一映射为 ErrTokenExpired 或 ErrTokenInvalid
+func (j *JWT) parse(tokenString, tokenType string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return j.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(j.config.Issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, errors.ErrTokenExpired.WithError(err)
+		}
+		return nil, errors.ErrTokenInvalid.WithError(err)
+	}
+
+	if claims.TokenType != tokenType {
+		return nil, errors.ErrTokenInvalid.WithDetailf("unexpected token type: %s", claims.TokenType)
+	}
+
+	return claims, nil
+}
+
+// Refresh 使用刷新令牌换取新的令牌对（刷新令牌轮换）
+//
+// 初级工程师学习要点：
+// - 验证刷新令牌后立即吊销它，保证每个刷新令牌只能使用一次
+// - 检查和吊销使用 RevokeOnce 一步完成，并发使用同一个刷新令牌时只有一个请求成功
+// - 如果刷新令牌已被吊销，说明可能被盗用，返回 ErrTokenInvalid
+// - 新令牌继承原令牌的 subject 和 Extra
+func (j *JWT) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	claims, err := j.parse(refreshToken, TokenTypeRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked, err := j.store.RevokeOnce(ctx, claims.ID, time.Until(claims.ExpiresAt.Time))
+	if err != nil {
+		return nil, errors.ErrCacheError.WithError(err)
+	}
+	if !revoked {
+		return nil, errors.ErrTokenInvalid.WithDetail("refresh token has already been used")
+	}
+
+	return j.GenerateTokenPair(claims.Subject, claims.Extra)
+}
+
+// Revoke 吊销令牌（如用户登出时同时吊销访问令牌和刷新令牌）
+func (j *JWT) Revoke(ctx context.Context, claims *Claims) error {
+	if claims == nil || claims.ExpiresAt == nil {
+		return nil
+	}
+	return j.store.Revoke(ctx, claims.ID, time.Until(claims.ExpiresAt.Time))
+}
+
+// JWTAuth 返回 JWT 认证中间件
+//
+// 初级工程师学习要点：
+// - 从 Authorization: Bearer <token> 读取令牌
+// - 签名和有效期验证通过后，还要检查令牌是否已被吊销（每个请求查询一次吊销存储）
+// - 验证通过后将 Claims 同时存入 gin.Context 和 Request.Context
+// - Handler 通过 GetClaims，Service 通过 ClaimsFromContext 获取当前用户
+//
+// 使用示例：
+//
+//	auth := v1.Group("/", middleware.JWTAuth(jwtMgr))
+//	auth.GET("/profile", handler.Profile)
+func JWTAuth(j *JWT) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString, ok := bearerToken(c.GetHeader("Authorization"))
+		if !ok {
+			response.Error(c, errors.ErrUnauthorized)
+			c.Abort()
+			return
+		}
+
+		claims, err := j.ParseAccessToken(tokenString)
+		if err != nil {
+			response.Error(c, err)
+			c.Abort()
+			return
+		}
+
+		// 已吊销的访问令牌（如登出时吊销）在过期前也不能再使用
+		revoked, err := j.store.IsRevoked(c.Request.Context(), claims.ID)
+		if err != nil {
+			response.Error(c, errors.ErrCacheError.WithError(err))
+			c.Abort()
+			return
+		}
+		if revoked {
+			response.Error(c, errors.ErrTokenInvalid.WithDetail("access token has been revoked"))
+			c.Abort()
+			return
+		}
+
+		c.Set(ClaimsKey, claims)
+		c.Request = c.Request.WithContext(WithClaims(c.Request.Context(), claims))
+
+		c.Next()
+	}
+}
+
+// bearerToken 从 Authorization 头中提取 Bearer 令牌
+func bearerToken(header string) (string, bool) {
+	const prefix = "Bearer "
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return "", false
+	}
+
+	token := strings.TrimSpace(header[len(prefix):])
+	return token, token != ""
+}
+
+// WithClaims 将 Claims 存入 Context
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsContextKey{}, claims)
+}
+
+// ClaimsFromContext 从 Context 中获取 Claims
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsContextKey{}).(*Claims)
+	return claims, ok
+}
+
+// GetClaims 从 gin.Context 中获取 Claims
+func GetClaims(c *gin.Context) (*Claims, bool) {
+	value, exists := c.Get(ClaimsKey)
+	if !exists {
+		return nil, false
+	}
+	claims, ok := value.(*Claims)
+	return claims, ok
+}
+
+// newTokenID 生成随机令牌 ID
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// ==================== 吊销存储实现 ====================
+
+// MemoryRevocationStore 进程内吊销存储
+//
+// 初级工程师学习要点：
+// - 使用 map 记录已吊销的 jti 及其过期时间
+// - 写入时顺带清理已过期的记录，避免内存无限增长
+// - 多实例部署时各实例数据不共享，生产环境请使用 RedisRevocationStore
+type MemoryRevocationStore struct {
+	mu      sync.Mutex
+	revoked map[string]time.Time
+}
+
+// NewMemoryRevocationStore 创建进程内吊销存储
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{
+		revoked: make(map[string]time.Time),
+	}
+}
+
+// Revoke 吊销令牌
+func (s *MemoryRevocationStore) Revoke(ctx context.Context, jti string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.sweep()
+	s.revoked[jti] = now.Add(ttl)
+	return nil
+}
+
+// RevokeOnce 令牌未被吊销时吊销它（检查和写入在同一把锁内完成）
+func (s *MemoryRevocationStore) RevokeOnce(ctx context.Context, jti string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.sweep()
+	if _, ok := s.revoked[jti]; ok {
+		return false, nil
+	}
+	s.revoked[jti] = now.Add(ttl)
+	return true, nil
+}
+
+// sweep 清理已过期的记录，返回当前时间（调用方需持有锁）
+func (s *MemoryRevocationStore) sweep() time.Time {
+	now := time.Now()
+	for id, expiresAt := range s.revoked {
+		if now.After(expiresAt) {
+			delete(s.revoked, id)
+		}
+	}
+	return now
+}
+
+// IsRevoked 检查令牌是否已被吊销
+func (s *MemoryRevocationStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt, ok := s.revoked[jti]
+	return ok && time.Now().Before(expiresAt), nil
+}
+
+// RedisRevocationStore 基于 Redis 的吊销存储
+//
+// 初级工程师学习要点：
+// - 使用 Redis key 的过期时间自动清理吊销记录
+// - 多实例共享吊销状态
+type RedisRevocationStore struct {
+	redis  *redis.Redis
+	prefix string
+}
+
+// NewRedisRevocationStore 创建基于 Redis 的吊销存储
+func NewRedisRevocationStore(rdb *redis.Redis) *RedisRevocationStore {
+	return &RedisRevocationStore{
+		redis:  rdb,
+		prefix: "jwt:revoked:",
+	}
+}
+
+// Revoke 吊销令牌
+func (s *RedisRevocationStore) Revoke(ctx context.Context, jti string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil // 令牌已过期，无需记录
+	}
+	return s.redis.Set(ctx, s.prefix+jti, 1, ttl)
+}
+
+// RevokeOnce 令牌未被吊销时吊销它（SET NX，多实例并发时只有一个成功）
+func (s *RedisRevocationStore) RevokeOnce(ctx context.Context, jti string, ttl time.Duration) (bool, error) {
+	if ttl <= 0 {
+		return false, nil // 令牌已过期，不能再使用
+	}
+	return s.redis.SetNX(ctx, s.prefix+jti, 1, ttl)
+}
+
+// IsRevoked 检查令牌是否已被吊销
+func (s *RedisRevocationStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	n, err := s.redis.Exists(ctx, s.prefix+jti)
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,269 @@
+// Package middleware 限流中间件
+package middleware
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jingpc/awesome-be/internal/config"
+	"github.com/jingpc/awesome-be/internal/logger"
+	"github.com/jingpc/awesome-be/internal/redis"
+	"github.com/jingpc/awesome-be/pkg/errors"
+	"github.com/jingpc/awesome-be/pkg/response"
+)
+
+// 限流维度
+const (
+	KeyByIP      = "ip"      // 按客户端 IP
+	KeyBySubject = "subject" // 按 JWT 认证主体（未认证时退化为 IP）
+	KeyByRoute   = "route"   // 按路由（所有客户端共享配额）
+)
+
+// RateLimiter 限流器
+//
+// 初级工程师学习要点：
+// - 优先使用 Redis 计数，Redis 出错时降级到进程内计数（不因为限流组件故障拒绝所有请求）
+// - 降级时多实例不再共享配额，所以每次降级都会计数，并按间隔记录告警日志
+// - Redis 出错后短时间内不再访问 Redis，避免故障期间每个请求都等待超时
+// - 规则按路由前缀匹配，最长前缀优先
+type RateLimiter struct {
+	primary  Limiter // 主限流器（Redis 或进程内）
+	fallback Limiter // 降级限流器（进程内）
+	global   RateLimitRule
+	routes   []routeRule // 按前缀长度降序排列
+	skip     []string
+
+	log          *logger.Logger
+	fallbacks    atomic.Int64 // 降级到进程内计数的次数
+	lastFallback atomic.Int64 // 上次记录降级日志的时间（UnixNano）
+	retryPrimary atomic.Int64 // 主限流器出错后，在此时间（UnixNano）之前直接降级
+}
+
+const (
+	// fallbackLogInterval 降级日志的最小间隔（Redis 故障期间每个请求都会降级，避免日志刷屏）
+	fallbackLogInterval = time.Minute
+
+	// primaryRetryInterval 主限流器出错后暂停使用的时间，之后由下一个请求重新尝试
+	primaryRetryInterval = time.Second
+)
+
+// errPrimaryBackoff 主限流器处于暂停期
+var errPrimaryBackoff = errors.ErrCacheError.WithDetail("rate limiter is backing off after a redis error")
+
+// routeRule 路由级限流规则
+type routeRule struct {
+	prefix string
+	rule   RateLimitRule
+}
+
+// NewRateLimiter 创建限流器
+//
+// 初级工程师学习要点：
+// - rdb 为 nil 时只使用进程内计数
+// - log 用于记录 Redis 出错降级的告警，为 nil 时不记录
+// - 路由级配置中未设置的字段继承全局配置
+func NewRateLimiter(cfg config.RateLimitConfig, rdb *redis.Redis, log *logger.Logger) *RateLimiter {
+	rl := &RateLimiter{
+		log:      log,
+		fallback: NewMemoryLimiter(),
+		global: RateLimitRule{
+			Name:      "global",
+			Algorithm: cfg.Algorithm,
+			KeyBy:     cfg.KeyBy,
+			Limit:     cfg.Requests,
+			Window:    cfg.Window,
+			Burst:     cfg.Burst,
+		},
+		skip: cfg.Skip,
+	}
+
+	rl.primary = rl.fallback
+	if rdb != nil {
+		rl.primary = NewRedisLimiter(rdb)
+	}
+
+	for _, route := range cfg.Routes {
+		rule := rl.global
+		rule.Name = route.Prefix
+		if route.Requests > 0 {
+			rule.Limit = route.Requests
+		}
+		if route.Window > 0 {
+			rule.Window = route.Window
+		}
+		if route.Algorithm != "" {
+			rule.Algorithm = route.Algorithm
+		}
+		if route.KeyBy != "" {
+			rule.KeyBy = route.KeyBy
+		}
+		if route.Burst > 0 {
+			rule.Burst = route.Burst
+		}
+		rl.routes = append(rl.routes, routeRule{prefix: route.Prefix, rule: rule})
+	}
+
+	sort.SliceStable(rl.routes, func(i, j int) bool {
+		return len(rl.routes[i].prefix) > len(rl.routes[j].prefix)
+	})
+
+	return rl
+}
+
+// RateLimit 返回全局限流中间件
+//
+// 使用示例：
+//
+//	engine.Use(middleware.RateLimit(cfg.Middleware.RateLimit, rdb, appLogger))
+//
+// 初级工程师学习要点：
+//   - 如果未启用，返回空中间件（不影响性能）
+//   - key_by 为 subject 时，需要在 JWTAuth 之后执行才能拿到认证主体，
+//     此时应使用 RateLimiter.Handler 挂载到需要认证的路由组上
+func RateLimit(cfg config.RateLimitConfig, rdb *redis.Redis, log *logger.Logger) gin.HandlerFunc {
+	if !cfg.Enabled {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	return NewRateLimiter(cfg, rdb, log).Handler()
+}
+
+// Handler 返回按配置匹配规则的限流中间件
+func (rl *RateLimiter) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		for _, prefix := range rl.skip {
+			if strings.HasPrefix(path, prefix) {
+				c.Next()
+				return
+			}
+		}
+
+		rl.handle(c, rl.match(path))
+	}
+}
+
+// HandlerWithRule 返回使用指定规则的限流中间件（用于在代码中给路由组单独限流）
+//
+// 使用示例：
+//
+//	auth := v1.Group("/auth", limiter.HandlerWithRule(middleware.RateLimitRule{
+//		Name: "auth", Algorithm: middleware.AlgorithmFixedWindow,
+//		KeyBy: middleware.KeyByIP, Limit: 5, Window: time.Minute,
+//	}))
+func (rl *RateLimiter) HandlerWithRule(rule RateLimitRule) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rl.handle(c, rule)
+	}
+}
+
+// match 按最长前缀匹配路由级规则，未匹配时使用全局规则
+func (rl *RateLimiter) match(path string) RateLimitRule {
+	for _, route := range rl.routes {
+		if strings.HasPrefix(path, route.prefix) {
+			return route.rule
+		}
+	}
+	return rl.global
+}
+
+// handle 执行限流判断并设置响应头
+func (rl *RateLimiter) handle(c *gin.Context, rule RateLimitRule) {
+	key := rule.Name + ":" + rule.KeyBy + ":" + rateLimitKey(c, rule.KeyBy)
+
+	result, err := rl.allowPrimary(c, key, rule)
+	if err != nil {
+		// Redis 不可用时降级到进程内计数
+		rl.onFallback(err)
+		if result, err = rl.fallback.Allow(c.Request.Context(), key, rule); err != nil {
+			// 进程内计数也失败时放行，不因为限流组件故障拒绝请求
+			c.Next()
+			return
+		}
+	}
+
+	// 按 IETF RateLimit 头字段草案设置响应头
+	c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(max(result.Remaining, 0)))
+	c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+	if !result.Allowed {
+		c.Header("Retry-After", strconv.Itoa(max(ceilSeconds(result.RetryAfter), 1)))
+		response.Error(c, errors.ErrRateLimitExceeded)
+		c.Abort()
+		return
+	}
+
+	c.Next()
+}
+
+// allowPrimary 使用主限流器计数，出错后的 primaryRetryInterval 内直接返回 errPrimaryBackoff
+func (rl *RateLimiter) allowPrimary(c *gin.Context, key string, rule RateLimitRule) (*RateLimitResult, error) {
+	if time.Now().UnixNano() < rl.retryPrimary.Load() {
+		return nil, errPrimaryBackoff
+	}
+
+	result, err := rl.primary.Allow(c.Request.Context(), key, rule)
+	if err != nil {
+		rl.retryPrimary.Store(time.Now().Add(primaryRetryInterval).UnixNano())
+	}
+	return result, err
+}
+
+// Fallbacks 返回降级到进程内计数的次数
+func (rl *RateLimiter) Fallbacks() int64 {
+	return rl.fallbacks.Load()
+}
+
+// onFallback 记录一次降级，每个间隔内最多输出一条告警日志
+func (rl *RateLimiter) onFallback(err error) {
+	count := rl.fallbacks.Add(1)
+	if rl.log == nil {
+		return
+	}
+
+	now := time.Now().UnixNano()
+	last := rl.lastFallback.Load()
+	if now-last < int64(fallbackLogInterval) || !rl.lastFallback.CompareAndSwap(last, now) {
+		return
+	}
+	rl.log.Warn("rate limiter falling back to in-memory counting, limits are no longer shared across instances",
+		"error", err,
+		"fallbacks", count,
+	)
+}
+
+// rateLimitKey 根据限流维度提取计数 key
+func rateLimitKey(c *gin.Context, keyBy string) string {
+	switch keyBy {
+	case KeyBySubject:
+		if claims, ok := GetClaims(c); ok && claims.Subject != "" {
+			return "sub:" + claims.Subject
+		}
+		return "ip:" + c.ClientIP()
+	case KeyByRoute:
+		// 未匹配路由（404）按 IP 计数，避免任意路径产生无限多的 key
+		if route := c.FullPath(); route != "" {
+			return c.Request.Method + " " + route
+		}
+		return "ip:" + c.ClientIP()
+	default:
+		return c.ClientIP()
+	}
+}
+
+// ceilSeconds 将时间向上取整为秒
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int(math.Ceil(d.Seconds()))
+}
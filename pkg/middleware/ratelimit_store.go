@@ -0,0 +1,363 @@
+// Package middleware 限流计数存储
+package middleware
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/jingpc/awesome-be/internal/redis"
+)
+
+// 限流算法
+const (
+	AlgorithmFixedWindow   = "fixed_window"   // 固定窗口
+	AlgorithmSlidingWindow = "sliding_window" // 滑动窗口（加权计数近似）
+	AlgorithmTokenBucket   = "token_bucket"   // 令牌桶
+)
+
+// RateLimitRule 限流规则
+type RateLimitRule struct {
+	Name      string        // 规则名称（区分不同路由组的计数）
+	Algorithm string        // 限流算法
+	KeyBy     string        // 限流维度
+	Limit     int           // 时间窗口内允许的请求数
+	Window    time.Duration // 时间窗口大小
+	Burst     int           // 令牌桶容量
+}
+
+// capacity 返回令牌桶容量（未配置时等于 Limit）
+func (r RateLimitRule) capacity() int {
+	if r.Burst > 0 {
+		return r.Burst
+	}
+	return r.Limit
+}
+
+// RateLimitResult 限流判断结果
+type RateLimitResult struct {
+	Allowed    bool          // 是否放行
+	Limit      int           // 配额上限
+	Remaining  int           // 剩余配额
+	Reset      time.Duration // 配额完全恢复所需时间
+	RetryAfter time.Duration // 被拒绝时建议的重试等待时间
+}
+
+// Limiter 限流器接口
+//
+// 初级工程师学习要点：
+// - 不同存储（Redis、进程内存）实现同一接口，中间件不关心计数存在哪里
+// - key 已包含规则名称和限流维度，同一个 key 只对应一条规则
+type Limiter interface {
+	Allow(ctx context.Context, key string, rule RateLimitRule) (*RateLimitResult, error)
+}
+
+// ==================== 进程内限流器 ====================
+
+// memoryEntry 进程内计数状态
+type memoryEntry struct {
+	// 固定窗口 / 滑动窗口
+	windowStart time.Time
+	count       int
+	prevCount   int
+
+	// 令牌桶
+	tokens float64
+	last   time.Time
+
+	// 过期时间（用于清理）
+	expiresAt time.Time
+}
+
+// MemoryLimiter 进程内限流器
+//
+// 初级工程师学习要点：
+// - 使用 map + 互斥锁保存每个 key 的计数
+// - 定期清理过期的 key，避免内存无限增长
+// - 多实例部署时各实例单独计数，整体配额 = 单实例配额 × 实例数
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	entries   map[string]*memoryEntry
+	lastSweep time.Time
+}
+
+// NewMemoryLimiter 创建进程内限流器
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		entries:   make(map[string]*memoryEntry),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow 判断请求是否放行
+func (l *MemoryLimiter) Allow(ctx context.Context, key string, rule RateLimitRule) (*RateLimitResult, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	entry, ok := l.entries[key]
+	if !ok {
+		entry = &memoryEntry{}
+		l.entries[key] = entry
+	}
+
+	switch rule.Algorithm {
+	case AlgorithmFixedWindow:
+		return l.fixedWindow(entry, rule, now), nil
+	case AlgorithmTokenBucket:
+		return l.tokenBucket(entry, rule, now), nil
+	default:
+		return l.slidingWindow(entry, rule, now), nil
+	}
+}
+
+// fixedWindow 固定窗口算法
+func (l *MemoryLimiter) fixedWindow(e *memoryEntry, rule RateLimitRule, now time.Time) *RateLimitResult {
+	if now.Sub(e.windowStart) >= rule.Window {
+		e.windowStart = now.Truncate(rule.Window)
+		e.count = 0
+	}
+	e.expiresAt = e.windowStart.Add(rule.Window)
+	reset := e.expiresAt.Sub(now)
+
+	if e.count >= rule.Limit {
+		return &RateLimitResult{Limit: rule.Limit, Reset: reset, RetryAfter: reset}
+	}
+
+	e.count++
+	return &RateLimitResult{Allowed: true, Limit: rule.Limit, Remaining: rule.Limit - e.count, Reset: reset}
+}
+
+// slidingWindow 滑动窗口算法
+//
+// 初级工程师学习要点：
+// - 估算值 = 上一窗口计数 × 上一窗口在滑动窗口内的占比 + 当前窗口计数
+// - 只需保存两个计数器，比记录每个请求时间戳节省内存
+func (l *MemoryLimiter) slidingWindow(e *memoryEntry, rule RateLimitRule, now time.Time) *RateLimitResult {
+	current := now.Truncate(rule.Window)
+	switch {
+	case current.Equal(e.windowStart):
+		// 仍在当前窗口
+	case current.Sub(e.windowStart) == rule.Window:
+		e.prevCount, e.count = e.count, 0
+		e.windowStart = current
+	default:
+		e.prevCount, e.count = 0, 0
+		e.windowStart = current
+	}
+	e.expiresAt = current.Add(2 * rule.Window)
+
+	elapsed := now.Sub(current)
+	weight := 1 - float64(elapsed)/float64(rule.Window)
+	estimated := int(math.Floor(float64(e.prevCount)*weight)) + e.count
+	reset := rule.Window - elapsed
+
+	if estimated >= rule.Limit {
+		return &RateLimitResult{Limit: rule.Limit, Reset: reset, RetryAfter: reset}
+	}
+
+	e.count++
+	return &RateLimitResult{Allowed: true, Limit: rule.Limit, Remaining: rule.Limit - estimated - 1, Reset: reset}
+}
+
+// tokenBucket 令牌桶算法
+//
+// 初级工程师学习要点：
+// - 令牌以 Limit/Window 的速率持续补充，最多累积到桶容量
+// - 每个请求消耗一个令牌，允许一定程度的突发流量
+func (l *MemoryLimiter) tokenBucket(e *memoryEntry, rule RateLimitRule, now time.Time) *RateLimitResult {
+	capacity := float64(rule.capacity())
+	rate := float64(rule.Limit) / float64(rule.Window) // 每纳秒补充的令牌数
+
+	if e.last.IsZero() {
+		e.tokens = capacity
+	} else {
+		e.tokens = math.Min(capacity, e.tokens+float64(now.Sub(e.last))*rate)
+	}
+	e.last = now
+
+	result := &RateLimitResult{Limit: rule.capacity()}
+	if e.tokens >= 1 {
+		e.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - e.tokens) / rate)
+	}
+
+	result.Remaining = int(e.tokens)
+	result.Reset = time.Duration((capacity - e.tokens) / rate)
+	e.expiresAt = now.Add(result.Reset)
+	return result
+}
+
+// sweep 清理过期的 key（每分钟最多执行一次）
+func (l *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	for key, entry := range l.entries {
+		if now.After(entry.expiresAt) {
+			delete(l.entries, key)
+		}
+	}
+}
+
+// ==================== Redis 限流器 ====================
+
+// fixedWindowScript 固定窗口 Lua 脚本
+// 返回：{ 当前计数, 剩余毫秒 }
+var fixedWindowScript = goredis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return { count, redis.call('PTTL', KEYS[1]) }
+`)
+
+// slidingWindowScript 滑动窗口 Lua 脚本
+// KEYS[1] 当前窗口，KEYS[2] 上一窗口
+// ARGV[1] 配额，ARGV[2] 窗口毫秒数，ARGV[3] 上一窗口权重（千分比）
+// 返回：{ 是否放行, 估算值 }
+var slidingWindowScript = goredis.NewScript(`
+local limit = tonumber(ARGV[1])
+local prev = tonumber(redis.call('GET', KEYS[2]) or '0')
+local curr = tonumber(redis.call('GET', KEYS[1]) or '0')
+local estimated = math.floor(prev * tonumber(ARGV[3]) / 1000) + curr
+if estimated >= limit then
+	return { 0, estimated }
+end
+redis.call('INCR', KEYS[1])
+redis.call('PEXPIRE', KEYS[1], tonumber(ARGV[2]) * 2)
+return { 1, estimated + 1 }
+`)
+
+// tokenBucketScript 令牌桶 Lua 脚本
+// ARGV[1] 容量，ARGV[2] 每毫秒补充的令牌数，ARGV[3] 当前毫秒时间戳
+// 返回：{ 是否放行, 剩余令牌（字符串，保留小数） }
+var tokenBucketScript = goredis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'last')
+local tokens = tonumber(state[1]) or capacity
+local last = tonumber(state[2]) or now
+tokens = math.min(capacity, tokens + math.max(0, now - last) * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'last', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(capacity / rate))
+return { allowed, tostring(tokens) }
+`)
+
+// RedisLimiter 基于 Redis 的限流器
+//
+// 初级工程师学习要点：
+// - 使用 Lua 脚本保证"读取-判断-写入"的原子性
+// - 多实例共享计数，整体配额准确
+// - 时间戳由应用传入，各实例需要保持时钟同步（NTP）
+type RedisLimiter struct {
+	redis  *redis.Redis
+	prefix string
+}
+
+// NewRedisLimiter 创建基于 Redis 的限流器
+func NewRedisLimiter(rdb *redis.Redis) *RedisLimiter {
+	return &RedisLimiter{
+		redis:  rdb,
+		prefix: "ratelimit:",
+	}
+}
+
+// Allow 判断请求是否放行
+func (l *RedisLimiter) Allow(ctx context.Context, key string, rule RateLimitRule) (*RateLimitResult, error) {
+	switch rule.Algorithm {
+	case AlgorithmFixedWindow:
+		return l.fixedWindow(ctx, key, rule)
+	case AlgorithmTokenBucket:
+		return l.tokenBucket(ctx, key, rule)
+	default:
+		return l.slidingWindow(ctx, key, rule)
+	}
+}
+
+// fixedWindow 固定窗口算法
+func (l *RedisLimiter) fixedWindow(ctx context.Context, key string, rule RateLimitRule) (*RateLimitResult, error) {
+	values, err := fixedWindowScript.Run(ctx, l.redis.Client(), []string{l.prefix + key}, rule.Window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	count, reset := int(values[0]), time.Duration(values[1])*time.Millisecond
+	if count > rule.Limit {
+		return &RateLimitResult{Limit: rule.Limit, Reset: reset, RetryAfter: reset}, nil
+	}
+	return &RateLimitResult{Allowed: true, Limit: rule.Limit, Remaining: rule.Limit - count, Reset: reset}, nil
+}
+
+// slidingWindow 滑动窗口算法
+func (l *RedisLimiter) slidingWindow(ctx context.Context, key string, rule RateLimitRule) (*RateLimitResult, error) {
+	now := time.Now()
+	current := now.Truncate(rule.Window)
+	index := current.UnixMilli() / rule.Window.Milliseconds()
+	elapsed := now.Sub(current)
+	weight := int64(1000 * (1 - float64(elapsed)/float64(rule.Window)))
+
+	// 脚本同时访问两个窗口的 key，用 {key} 作为 hash tag 保证两个 key 在 Redis Cluster 的同一个槽，
+	// 否则 cluster 模式下每次调用都报 CROSSSLOT 错误
+	keys := []string{
+		l.prefix + "{" + key + "}:" + strconv.FormatInt(index, 10),
+		l.prefix + "{" + key + "}:" + strconv.FormatInt(index-1, 10),
+	}
+	values, err := slidingWindowScript.Run(ctx, l.redis.Client(), keys, rule.Limit, rule.Window.Milliseconds(), weight).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	reset := rule.Window - elapsed
+	if values[0] == 0 {
+		return &RateLimitResult{Limit: rule.Limit, Reset: reset, RetryAfter: reset}, nil
+	}
+	return &RateLimitResult{Allowed: true, Limit: rule.Limit, Remaining: rule.Limit - int(values[1]), Reset: reset}, nil
+}
+
+// tokenBucket 令牌桶算法
+func (l *RedisLimiter) tokenBucket(ctx context.Context, key string, rule RateLimitRule) (*RateLimitResult, error) {
+	capacity := rule.capacity()
+	rate := float64(rule.Limit) / float64(rule.Window.Milliseconds()) // 每毫秒补充的令牌数
+
+	values, err := tokenBucketScript.Run(ctx, l.redis.Client(), []string{l.prefix + key},
+		capacity, strconv.FormatFloat(rate, 'f', -1, 64), time.Now().UnixMilli()).Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	allowed, _ := values[0].(int64)
+	tokensStr, _ := values[1].(string)
+	tokens, err := strconv.ParseFloat(tokensStr, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	perToken := float64(time.Millisecond) / rate
+	result := &RateLimitResult{
+		Allowed:   allowed == 1,
+		Limit:     capacity,
+		Remaining: int(tokens),
+		Reset:     time.Duration((float64(capacity) - tokens) * perToken),
+	}
+	if !result.Allowed {
+		result.RetryAfter = time.Duration((1 - tokens) * perToken)
+	}
+	return result, nil
+}
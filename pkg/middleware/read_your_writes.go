@@ -0,0 +1,117 @@
+// Package middleware 读写一致性中间件
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jingpc/awesome-be/internal/database"
+)
+
+// lastWriteCookie 记录最后写入时间的 Cookie 名称
+const lastWriteCookie = "_db_last_write"
+
+// ReadYourWrites 返回读写一致性中间件
+//
+// 初级工程师学习要点：
+// - 为每个请求创建 database.Session，写入主库成功后自动记录写入时间
+// - 同一请求内：写入后 window 时间内，Database.Slave 返回主库
+// - 跨请求：写入后在响应头中下发一个 Cookie（有效期为 window），下一个请求（如下单后跳转到订单详情）恢复写入时间，继续读主库
+// - Cookie 只是一个时间戳，伪造最多让读请求在 window 内走主库，不会影响数据正确性
+//
+// 使用示例：
+//
+//	engine.Use(middleware.ReadYourWrites(3 * time.Second))
+func ReadYourWrites(window time.Duration) gin.HandlerFunc {
+	// 如果未启用，返回空中间件
+	if window <= 0 {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	maxAge := int(math.Ceil(window.Seconds()))
+
+	return func(c *gin.Context) {
+		session := database.NewSession()
+
+		// 恢复上一个请求的写入时间
+		if value, err := c.Cookie(lastWriteCookie); err == nil {
+			if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+				session.Restore(time.UnixMilli(ms))
+			}
+		}
+
+		// 写库回调可能在任意 goroutine 中执行，回调只记录时间，Cookie 由 writer 在响应头发送前设置
+		writer := &lastWriteWriter{ResponseWriter: c.Writer, c: c, session: session, maxAge: maxAge}
+		c.Writer = writer
+
+		c.Request = c.Request.WithContext(database.WithSession(c.Request.Context(), session))
+		c.Next()
+
+		// 没有写响应体的请求（如只设置了状态码），响应头在中间件返回后才发送
+		writer.setCookie()
+	}
+}
+
+// lastWriteWriter 在响应头发送前下发写入时间 Cookie
+//
+// 初级工程师学习要点：
+// - 响应头一旦发送就不能再设置 Cookie，所以在 Write、WriteHeader、Flush 之前检查是否写过库
+// - 响应头发送之后才写库（如流式响应）时不再下发 Cookie，下一个请求可能读到从库的旧数据
+type lastWriteWriter struct {
+	gin.ResponseWriter
+	c       *gin.Context
+	session *database.Session
+	maxAge  int
+	done    bool
+}
+
+// setCookie 本次请求写过库且响应头还没有发送时设置 Cookie（只设置一次）
+func (w *lastWriteWriter) setCookie() {
+	if w.done || w.ResponseWriter.Written() {
+		return
+	}
+	last := w.session.LatestWrite()
+	if last.IsZero() {
+		return
+	}
+
+	w.done = true
+	w.c.SetSameSite(http.SameSiteLaxMode)
+	w.c.SetCookie(lastWriteCookie, strconv.FormatInt(last.UnixMilli(), 10), w.maxAge, "/", "", w.c.Request.TLS != nil, true)
+}
+
+// WriteHeader 设置状态码前先设置 Cookie
+func (w *lastWriteWriter) WriteHeader(code int) {
+	w.setCookie()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// WriteHeaderNow 发送响应头前先设置 Cookie
+func (w *lastWriteWriter) WriteHeaderNow() {
+	w.setCookie()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Write 写响应体前先设置 Cookie
+func (w *lastWriteWriter) Write(data []byte) (int, error) {
+	w.setCookie()
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString 写响应体前先设置 Cookie
+func (w *lastWriteWriter) WriteString(s string) (int, error) {
+	w.setCookie()
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Flush 刷新响应前先设置 Cookie
+func (w *lastWriteWriter) Flush() {
+	w.setCookie()
+	w.ResponseWriter.Flush()
+}
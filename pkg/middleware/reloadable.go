@@ -0,0 +1,45 @@
+// Package middleware 可热更新的中间件
+package middleware
+
+import (
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Reloadable 可在运行时替换的中间件
+//
+// 初级工程师学习要点：
+// - Gin 的中间件在启动时注册，之后不能修改，配置变化后无法直接生效
+// - Reloadable 注册一个固定的外层中间件，实际处理交给内部的 HandlerFunc
+// - 配置变化时调用 Swap 替换内部 HandlerFunc，之后的请求立即使用新配置
+// - 使用 atomic.Pointer，请求处理时读取无锁
+//
+// 使用示例：
+//
+//	cors := middleware.NewReloadable(middleware.CORS(cfg.Middleware.CORS))
+//	engine.Use(cors.Handler())
+//	// 配置变化后
+//	cors.Swap(middleware.CORS(newCfg.Middleware.CORS))
+type Reloadable struct {
+	handler atomic.Pointer[gin.HandlerFunc]
+}
+
+// NewReloadable 创建可热更新的中间件
+func NewReloadable(handler gin.HandlerFunc) *Reloadable {
+	r := &Reloadable{}
+	r.Swap(handler)
+	return r
+}
+
+// Handler 返回注册到 Gin 的中间件
+func (r *Reloadable) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		(*r.handler.Load())(c)
+	}
+}
+
+// Swap 替换内部中间件
+func (r *Reloadable) Swap(handler gin.HandlerFunc) {
+	r.handler.Store(&handler)
+}
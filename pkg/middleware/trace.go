@@ -0,0 +1,153 @@
+// Package middleware 链路追踪中间件
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/jingpc/awesome-be/internal/config"
+	"github.com/jingpc/awesome-be/internal/logger"
+	"github.com/jingpc/awesome-be/internal/tracing"
+)
+
+// maxTraceIDLength 外部传入 TraceID 的最大长度
+const maxTraceIDLength = 128
+
+// tracerName HTTP 中间件使用的 Tracer 名称
+const tracerName = "github.com/jingpc/awesome-be/pkg/middleware"
+
+// Trace 返回链路追踪中间件
+//
+// 初级工程师学习要点：
+// - 优先从 W3C traceparent/tracestate 请求头中恢复上游的追踪上下文
+// - 没有 traceparent 时，使用 cfg.Header 指定的请求头中的 TraceID（32 位十六进制时直接沿用）
+// - 为每个请求创建一个服务端 Span，后续的数据库、Redis 操作会成为它的子 Span
+// - TraceID 统一存入 Request.Context（logger.WithTraceID），日志和响应都从这里读取
+// - 响应头回写 TraceID 和 traceparent，方便客户端反馈问题时提供
+//
+// 使用示例：
+//
+//	engine.Use(middleware.Trace(cfg.Middleware.Trace))
+//
+// 架构思路：
+// - 应该放在中间件链的最前面，保证后续中间件（日志、Recovery）都能拿到 TraceID
+// - TracerProvider 由 internal/tracing 初始化为全局实例，这里通过 otel.Tracer 获取
+func Trace(cfg config.TraceConfig) gin.HandlerFunc {
+	// 如果未启用，返回空中间件
+	if !cfg.Enabled {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	header := cfg.Header
+	if header == "" {
+		header = "X-Trace-ID"
+	}
+
+	tracer := otel.Tracer(tracerName)
+	propagator := otel.GetTextMapPropagator()
+
+	return func(c *gin.Context) {
+		// 1. 恢复上游追踪上下文
+		ctx := propagator.Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		upstreamID := c.GetHeader(header)
+		if !trace.SpanContextFromContext(ctx).IsValid() {
+			if traceID, err := trace.TraceIDFromHex(upstreamID); err == nil {
+				// 上游只传了自定义 TraceID（W3C 格式），根 Span 沿用该 TraceID
+				ctx = tracing.WithUpstreamTraceID(ctx, traceID)
+			}
+		}
+
+		// 2. 创建服务端 Span
+		route := c.FullPath()
+		spanName := c.Request.Method + " " + route
+		if route == "" {
+			spanName = c.Request.Method
+		}
+
+		ctx, span := tracer.Start(ctx, spanName,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Request.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", c.Request.URL.Path),
+				attribute.String("client.address", c.ClientIP()),
+				attribute.String("user_agent.original", c.Request.UserAgent()),
+			),
+		)
+		defer span.End()
+
+		// 3. 确定 TraceID
+		traceID := span.SpanContext().TraceID().String()
+		if !span.SpanContext().IsValid() {
+			// 未初始化 TracerProvider 时退化为自定义 TraceID
+			traceID = upstreamID
+			if !isValidTraceID(traceID) {
+				traceID = NewTraceID()
+			}
+		} else if upstreamID != "" && upstreamID != traceID && isValidTraceID(upstreamID) {
+			// 上游自定义 TraceID 不是 W3C 格式，记录到 Span 上便于关联
+			span.SetAttributes(attribute.String("trace.upstream_id", upstreamID))
+		}
+
+		// 4. 存入 Request.Context，供日志和响应读取
+		c.Request = c.Request.WithContext(logger.WithTraceID(ctx, traceID))
+
+		// 5. 回写响应头
+		c.Header(header, traceID)
+		propagator.Inject(ctx, propagation.HeaderCarrier(c.Writer.Header()))
+
+		c.Next()
+
+		// 6. 记录响应状态
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
+
+// NewTraceID 生成新的 TraceID（32 位十六进制字符串）
+//
+// 初级工程师学习要点：
+// - 使用 crypto/rand 生成 16 字节随机数，碰撞概率极低
+// - 长度和格式与 W3C Trace Context 的 trace-id 一致
+func NewTraceID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// isValidTraceID 检查外部传入的 TraceID 是否合法
+//
+// 初级工程师学习要点：
+// - 外部输入不可信，限制长度和字符集，防止日志注入和超长字段
+func isValidTraceID(traceID string) bool {
+	if traceID == "" || len(traceID) > maxTraceIDLength {
+		return false
+	}
+
+	for _, ch := range traceID {
+		switch {
+		case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z', ch >= '0' && ch <= '9':
+		case ch == '-' || ch == '_' || ch == '.':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
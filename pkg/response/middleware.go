@@ -31,7 +31,7 @@ func Recovery(log *logger.Logger) gin.HandlerFunc {
 				stack := string(debug.Stack())
 
 				// 记录 Panic 日志
-				log.Error("panic recovered",
+				log.ErrorContext(c.Request.Context(), "panic recovered",
 					zap.Any("error", err),
 					zap.String("stack", stack),
 					zap.String("method", c.Request.Method),
@@ -16,6 +16,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/jingpc/awesome-be/internal/logger"
 	"github.com/jingpc/awesome-be/pkg/errors"
 )
 
@@ -99,28 +100,9 @@ func ErrorWithMsg(c *gin.Context, code errors.Code, message string) {
 // getTraceID 从 Context 获取 TraceID
 //
 // 初级工程师学习要点：
-// - TraceID 由链路追踪中间件设置
-// - 存储在 gin.Context 中
+// - TraceID 由链路追踪中间件（middleware.Trace）设置
+// - 统一存储在 Request.Context 中（logger.WithTraceID），与日志读取同一位置
 // - 用于关联日志和请求
 func getTraceID(c *gin.Context) string {
-	// 从 gin.Context 获取
-	if traceID, exists := c.Get("trace_id"); exists {
-		if id, ok := traceID.(string); ok {
-			return id
-		}
-	}
-
-	// 从 Request.Context 获取
-	if traceID := c.Request.Context().Value("trace_id"); traceID != nil {
-		if id, ok := traceID.(string); ok {
-			return id
-		}
-	}
-
-	// 从 Header 获取
-	if traceID := c.GetHeader("X-Trace-ID"); traceID != "" {
-		return traceID
-	}
-
-	return ""
+	return logger.GetTraceID(c.Request.Context())
 }